@@ -0,0 +1,235 @@
+package subexpnames
+
+import "regexp"
+
+// BytesMatchValue is like MatchValue but represents a match found in a []byte subject.
+// Value is a sub-slice of the subject, no copy is made: it shares the subject's memory and must not be modified.
+// Use String to get a copy of the value as a string.
+type BytesMatchValue struct {
+	Key     string
+	Value   []byte
+	Matched bool
+	// capture is the index of the capture group of the BytesMatchValue in the Pattern that built it, see paths.
+	capture int32
+	Nested  []*BytesMatchValue
+	// start and end represent the indexes of the match in the subject, they are not exported.
+	start, end int
+	// paths is the pathTable of the Pattern that built the BytesMatchValue, nil if it was not built by an indexed Pattern.
+	paths *pathTable
+}
+
+// BytesMatches represents a collection of BytesMatchValue pointers.
+// It is used to store multiple matches found in a []byte subject that match a regular expression.
+type BytesMatches []*BytesMatchValue
+
+func (mv *BytesMatchValue) key() string                { return mv.Key }
+func (mv *BytesMatchValue) matched() bool              { return mv.Matched }
+func (mv *BytesMatchValue) nested() []*BytesMatchValue { return mv.Nested }
+func (mv *BytesMatchValue) table() (*pathTable, int)   { return mv.paths, int(mv.capture) }
+func (mv *BytesMatchValue) keyIndex() *keyIndex        { return nil }
+
+// String returns a copy of the value as a string.
+func (mv *BytesMatchValue) String() string {
+	return string(mv.Value)
+}
+
+// Start returns the byte index in the subject where the match starts.
+// It returns -1 if the capture group did not take part in the match.
+func (mv *BytesMatchValue) Start() int {
+	return mv.start
+}
+
+// End returns the byte index in the subject where the match ends, the match is subject[Start():End()].
+// It returns -1 if the capture group did not take part in the match.
+func (mv *BytesMatchValue) End() int {
+	return mv.end
+}
+
+// Span returns the byte indexes in the subject where the match starts and ends.
+// It returns -1, -1 if the capture group did not take part in the match.
+func (mv *BytesMatchValue) Span() (start, end int) {
+	return mv.start, mv.end
+}
+
+// bytesValue returns the sub-slice of subject between start and end.
+// Capture groups that do not take part in the match have negative indexes, their value is nil.
+func bytesValue(subject []byte, start, end int) []byte {
+	if start < 0 || end < 0 {
+		return nil
+	}
+	return subject[start:end:end]
+}
+
+// bytesTree is like tree but builds the hierarchical structure of matches found in a []byte subject.
+func (p *Pattern) bytesTree(subject []byte, indexes [][]int) *BytesMatches {
+	matches := make([]*BytesMatchValue, 0, len(indexes))
+	for i := 0; i < len(indexes); i++ {
+		matches = append(matches, p.bytesBuild(subject, indexes[i]))
+	}
+	return (*BytesMatches)(&matches)
+}
+
+// bytesBuild is like build but returns the tree of a single match found in a []byte subject, it is built the same way buildNodes builds it.
+func (p *Pattern) bytesBuild(subject []byte, indexes []int) *BytesMatchValue {
+	names := p.names
+	// nodes holds the BytesMatchValue of each capture group of the match, parents always come before their children.
+	nodes := make([]BytesMatchValue, len(names))
+	nested := make([]*BytesMatchValue, len(names)-1)
+	for j := range names {
+		start, end := indexes[2*j], indexes[2*j+1]
+		nodes[j] = BytesMatchValue{
+			Key:     names[j],
+			Value:   bytesValue(subject, start, end),
+			Matched: j == 0 || start >= 0,
+			capture: int32(j),
+			start:   start,
+			end:     end,
+			paths:   p.paths,
+			Nested:  nested[:0:p.children[j]],
+		}
+		nested = nested[p.children[j]:]
+		if j > 0 {
+			bound := &nodes[p.parents[j]]
+			bound.Nested = append(bound.Nested, &nodes[j])
+		}
+	}
+	return &nodes[0]
+}
+
+// MatchBytes is like Match but matches a []byte subject, values are sub-slices of the subject and no copy is made.
+// If a match is found, it returns a BytesMatches object containing the tree-like structure of matchValues.
+// Otherwise, it returns nil and false.
+// MatchBytes works out the hierarchy of the capture groups on every call, parsing the regular expression again, use Compile or NewPattern when matching the same regular expression repeatedly.
+func MatchBytes(regexp *regexp.Regexp, subject []byte) (*BytesMatches, bool) {
+	return NewPattern(regexp).MatchBytes(subject)
+}
+
+// MatchBytes is like Match but matches a []byte subject, values are sub-slices of the subject and no copy is made.
+// If a match is found, it returns a BytesMatches object containing the tree-like structure of matchValues.
+// Otherwise, it returns nil and false.
+func (p *Pattern) MatchBytes(subject []byte) (*BytesMatches, bool) {
+	indexes := p.re.FindAllSubmatchIndex(subject, -1)
+	if indexes == nil {
+		return nil, false
+	}
+	return p.bytesTree(subject, indexes), true
+}
+
+// GetAll retrieves all the values that match the provided keys from the specified match.
+// If the match or keys are not found, it returns nil and false.
+// Otherwise, it returns the matching values, which are sub-slices of the subject, and true.
+// Capture groups that did not take part in the match are returned as nil slices, see GetAllMatched.
+func (rm *BytesMatches) GetAll(group int, keys ...string) ([][]byte, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	values := bytesValuesOf(descend((*rm)[group], false, keys...))
+	return values, len(values) > 0
+}
+
+// GetAllMatched is like GetAll but skips the capture groups that did not take part in the match.
+func (rm *BytesMatches) GetAllMatched(group int, keys ...string) ([][]byte, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	values := bytesValuesOf(descend((*rm)[group], true, keys...))
+	return values, len(values) > 0
+}
+
+// GetAllStrings is like GetAll but returns copies of the values as strings.
+func (rm *BytesMatches) GetAllStrings(group int, keys ...string) ([]string, bool) {
+	values, ok := rm.GetAll(group, keys...)
+	if !ok {
+		return nil, false
+	}
+	strings := make([]string, len(values))
+	for i, v := range values {
+		strings[i] = string(v)
+	}
+	return strings, true
+}
+
+// Get retrieves the value at the specified index from the specified match.
+// If the match, value, or keys are not found, it returns nil and false.
+func (rm *BytesMatches) Get(group int, value int, keys ...string) ([]byte, bool) {
+	values, ok := rm.GetAll(group, keys...)
+	if !ok {
+		return nil, false
+	}
+
+	if value < 0 || value >= len(values) {
+		return nil, false
+	}
+
+	return values[value], true
+}
+
+// GetMatched is like Get but skips the capture groups that did not take part in the match, value indexes only the values that did.
+func (rm *BytesMatches) GetMatched(group int, value int, keys ...string) ([]byte, bool) {
+	values, ok := rm.GetAllMatched(group, keys...)
+	if !ok {
+		return nil, false
+	}
+
+	if value < 0 || value >= len(values) {
+		return nil, false
+	}
+
+	return values[value], true
+}
+
+// GetString is like Get but returns a copy of the value as a string.
+func (rm *BytesMatches) GetString(group int, value int, keys ...string) (string, bool) {
+	v, ok := rm.Get(group, value, keys...)
+	return string(v), ok
+}
+
+// GetFirstValueOfGroup retrieves the first value of the group that matches the provided keys.
+// If the keys sequence is not found, it returns nil and false.
+func (rm *BytesMatches) GetFirstValueOfGroup(group int, keys ...string) ([]byte, bool) {
+	return rm.Get(group, 0, keys...)
+}
+
+// GetGroup retrieves the match at the specified index from the BytesMatches object.
+// If the index is out of bounds, it returns nil and false.
+func (rm *BytesMatches) GetGroup(group int) (*BytesMatchValue, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	return (*rm)[group], true
+}
+
+// Len returns the number of groups in the BytesMatches object.
+func (rm *BytesMatches) Len() int {
+	return len(*rm)
+}
+
+// Keys retrieves all the keys from the specified group, see Matches.Keys.
+func (rm *BytesMatches) Keys(group int) [][]string {
+	if group < 0 || group >= len(*rm) {
+		return nil
+	}
+	return keysOf((*rm)[group])
+}
+
+// Unmatched retrieves the keys of the capture groups of the specified group that did not take part in the match, see Matches.Unmatched.
+func (rm *BytesMatches) Unmatched(group int) [][]string {
+	if group < 0 || group >= len(*rm) {
+		return nil
+	}
+	var keys [][]string
+	descendKeys((*rm)[group], &keys, &keySet{}, true)
+	return keys
+}
+
+// bytesValuesOf returns the Value of each of the nodes.
+func bytesValuesOf(nodes []*BytesMatchValue) [][]byte {
+	if nodes == nil {
+		return nil
+	}
+	values := make([][]byte, len(nodes))
+	for i, mv := range nodes {
+		values[i] = mv.Value
+	}
+	return values
+}
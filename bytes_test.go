@@ -0,0 +1,125 @@
+package subexpnames_test
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestMatchBytes(t *testing.T) {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	subject := "this is a test subject to see if we can parse 2016-01-02 and 1234-56-78 using the Match() function."
+
+	if _, ok := subexpnames.MatchBytes(re, []byte("not a match")); ok {
+		t.Fatalf("expected not a match")
+	}
+
+	b := []byte(subject)
+	match, ok := subexpnames.MatchBytes(re, b)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	expected, _ := subexpnames.Match(re, subject)
+	if match.Len() != expected.Len() {
+		t.Fatalf("expected %d groups, got %d", expected.Len(), match.Len())
+	}
+	for group := 0; group < match.Len(); group++ {
+		keys := match.Keys(group)
+		if !slices.EqualFunc(keys, expected.Keys(group), slices.Equal[[]string]) {
+			t.Fatalf("expected %v, got %v", expected.Keys(group), keys)
+		}
+		for _, keys := range keys {
+			want, _ := expected.GetAll(group, keys...)
+			got, _ := match.GetAllStrings(group, keys...)
+			if !slices.Equal(want, got) {
+				t.Fatalf("%v: expected %q, got %q", keys, want, got)
+			}
+		}
+	}
+
+	// values share the memory of the subject
+	year, ok := match.Get(0, 0, "overlap", "year")
+	if !ok || string(year) != "2016" {
+		t.Fatalf("expected 2016, got %q", year)
+	}
+	mv, _ := match.GetGroup(0)
+	start, end := mv.Nested[0].Nested[0].Span()
+	if &year[0] != &b[start] || end-start != len(year) || mv.Nested[0].Nested[0].Start() != start || mv.Nested[0].Nested[0].End() != end {
+		t.Fatalf("expected the value to be a sub-slice of the subject")
+	}
+	if cap(year) != len(year) {
+		t.Fatalf("expected appending to a value not to overwrite the subject")
+	}
+
+	if v, ok := match.GetString(1, 0, "overlap", "day"); !ok || v != "78" {
+		t.Fatalf("expected 78, got %q", v)
+	}
+	if v, ok := match.GetFirstValueOfGroup(1, "overlap"); !ok || string(v) != "1234-56" {
+		t.Fatalf("expected 1234-56, got %q", v)
+	}
+	if mv.String() != "2016-01-02" || mv.Key != subexpnames.RootKey {
+		t.Fatalf("expected 2016-01-02, got %q", mv.String())
+	}
+
+	if _, ok := match.GetAll(3); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := match.GetAllStrings(0, "not-found"); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := match.Get(0, 6, "overlap"); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := match.Get(0, 0, "not-found"); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := match.GetGroup(3); ok {
+		t.Fatalf("expected not found")
+	}
+	if match.Keys(3) != nil || match.Unmatched(3) != nil {
+		t.Fatalf("expected no keys")
+	}
+}
+
+func TestMatchBytesUnmatchedGroups(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<date>(?P<year>\d{4}-)?(?P<month>\d{2})-(?P<day>\d{2}))`)
+	match, ok := p.MatchBytes([]byte("03-04"))
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	if v, ok := match.Get(0, 0, "date", "year"); !ok || v != nil {
+		t.Fatalf("expected a nil year, got %q", v)
+	}
+	if _, ok := match.GetMatched(0, 0, "date", "year"); ok {
+		t.Fatalf("expected year to be skipped")
+	}
+	if _, ok := match.GetAllMatched(1); ok {
+		t.Fatalf("expected not found")
+	}
+	if v, ok := match.GetMatched(0, 0, "date", "day"); !ok || !bytes.Equal(v, []byte("04")) {
+		t.Fatalf("expected 04, got %q", v)
+	}
+	if _, ok := match.GetMatched(0, 1, "date", "day"); ok {
+		t.Fatalf("expected not found")
+	}
+	if unmatched := match.Unmatched(0); len(unmatched) != 1 || !slices.Equal(unmatched[0], []string{"date", "year"}) {
+		t.Fatalf("expected [[date year]], got %v", unmatched)
+	}
+	if mv, _ := match.GetGroup(0); mv.Nested[0].Nested[0].Matched || mv.Nested[0].Nested[0].Start() != -1 {
+		t.Fatalf("expected year not to be matched")
+	}
+}
+
+func BenchmarkMatchBytes(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := []byte(logSubject(1 << 20))
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.MatchBytes(subject)
+	}
+}
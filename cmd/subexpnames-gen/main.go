@@ -0,0 +1,363 @@
+// Subexpnames-gen writes a Go struct type mirroring the hierarchy of the named capture groups of a regular expression,
+// together with functions that fill it from the matches of the regular expression without using reflection.
+//
+// It is meant to be run by go generate, for example:
+//
+//	//go:generate subexpnames-gen -type Record -pattern "(?P<overlap>(?P<year>(?P<tens>[0-9]{2})(?P<ones>[0-9]{2}))-(?P<month>[0-9]{2}))"
+//
+// writes record_subexp.go, in the package of the file holding the directive, with:
+//
+//	type Record struct {
+//		Value   string
+//		Overlap []RecordOverlap
+//	}
+//
+//	type RecordOverlap struct {
+//		Value string
+//		Year  RecordOverlapYear
+//		Month string
+//	}
+//
+//	type RecordOverlapYear struct {
+//		Value string
+//		Tens  string
+//		Ones  string
+//	}
+//
+//	func ParseRecord(subject string) (Record, bool)
+//	func ParseAllRecord(subject string) []Record
+//
+// so that a value is read as rec.Overlap[0].Year.Tens and checked by the compiler, instead of passing keys to Matches.Get.
+//
+// Each named capture group becomes a field named after it in CamelCase, capture groups with the same name and parent are merged into a single field.
+// A capture group with nested named groups becomes a struct type, holding the value of the group in its Value field, otherwise it becomes a string.
+// A capture group that can appear more than once in the same parent becomes a slice.
+// Unnamed capture groups are transparent: their nested named groups become fields of the parent.
+// Capture groups that do not take part in a match are skipped, so they leave fields empty and are not appended to slices.
+// A struct type cannot take the name of the variable holding the Pattern or of the parse functions, the generation fails if it would.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+var (
+	typeName    = flag.String("type", "", "name of the struct type to generate; required")
+	pattern     = flag.String("pattern", "", "regular expression the struct type mirrors; required")
+	packageName = flag.String("package", "", "name of the package of the generated file; default $GOPACKAGE, set by go generate")
+	output      = flag.String("output", "", "name of the generated file, - writes to the standard output; default <type>_subexp.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of subexpnames-gen:\n")
+	fmt.Fprintf(os.Stderr, "\tsubexpnames-gen -type T -pattern regexp [flags]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("subexpnames-gen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeName == "" || *pattern == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pkg := *packageName
+	if pkg == "" {
+		pkg = os.Getenv("GOPACKAGE")
+	}
+	if pkg == "" {
+		log.Fatal("-package is required when not run by go generate")
+	}
+	src, err := generate(pkg, *typeName, *pattern)
+	if err != nil {
+		log.Fatal(err)
+	}
+	name := *output
+	if name == "" {
+		name = strings.ToLower(*typeName) + "_subexp.go"
+	}
+	if name == "-" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(name, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// group describes the struct type generated for the capture groups with the same path.
+type group struct {
+	typeName string
+	// path is the path of the capture groups, see subexpnames.Path, empty for the root.
+	path   string
+	fields []*field
+	byKey  map[string]*field
+	// unnamed is set if the capture groups have unnamed nested groups, their nested groups are filled as if they were nested in the group.
+	unnamed bool
+}
+
+// field describes the field generated for the nested capture groups with the same key.
+type field struct {
+	key  string
+	name string
+	// repeated is set if the capture group can appear more than once in the same parent.
+	repeated bool
+	// nodes are the capture groups of the skeleton the field is filled from.
+	nodes []*subexpnames.MatchValue
+	// group is the struct type of the field, nil if the field is a string.
+	group *group
+}
+
+// generator holds the state of the generation of a file.
+type generator struct {
+	buf   bytes.Buffer
+	types map[string]bool
+	// groups holds the struct types to generate, the root first.
+	groups []*group
+}
+
+// generate returns the formatted source of the file declaring the struct type named typeName for the regular expression expr in the package pkg.
+func generate(pkg, typeName, expr string) ([]byte, error) {
+	if !token.IsIdentifier(typeName) {
+		return nil, fmt.Errorf("invalid type name %q", typeName)
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{types: make(map[string]bool)}
+	// the names of the variable and the functions are declared in the same scope as the struct types, they cannot be used by them.
+	patternName, parseName, parseAllName := declaredNames(typeName)
+	g.types[patternName], g.types[parseName], g.types[parseAllName] = true, true, true
+	root := subexpnames.NewPattern(re).Skeleton()
+	if _, err := g.collect(typeName, "", []*subexpnames.MatchValue{root}); err != nil {
+		return nil, err
+	}
+	g.write(pkg, typeName, expr)
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("internal error: invalid generated code: %v", err)
+	}
+	return src, nil
+}
+
+// collect builds the struct type named typeName for the capture groups nodes, found at path, and the struct types of their nested groups.
+func (g *generator) collect(typeName, path string, nodes []*subexpnames.MatchValue) (*group, error) {
+	if g.types[typeName] {
+		return nil, fmt.Errorf("capture groups %q: type name %s is already used", path, typeName)
+	}
+	g.types[typeName] = true
+	grp := &group{typeName: typeName, path: path, byKey: make(map[string]*field)}
+	g.groups = append(g.groups, grp)
+	for _, node := range nodes {
+		count := make(map[string]int)
+		for _, inner := range named(node, &grp.unnamed) {
+			f, ok := grp.byKey[inner.Key]
+			if !ok {
+				f = &field{key: inner.Key, name: fieldName(inner.Key)}
+				for _, other := range grp.fields {
+					if other.name == f.name {
+						return nil, fmt.Errorf("capture groups %q and %q of %s have the same field name %s", other.key, f.key, typeName, f.name)
+					}
+				}
+				if f.name == "Value" {
+					return nil, fmt.Errorf("capture group %q of %s: field name Value is used by the value of the group", f.key, typeName)
+				}
+				grp.byKey[f.key] = f
+				grp.fields = append(grp.fields, f)
+			}
+			f.nodes = append(f.nodes, inner)
+			count[inner.Key]++
+			f.repeated = f.repeated || count[inner.Key] > 1
+		}
+	}
+	for _, f := range grp.fields {
+		nested := false
+		for _, node := range f.nodes {
+			nested = nested || len(named(node, new(bool))) > 0
+		}
+		if !nested {
+			continue
+		}
+		fieldPath := f.key
+		if path != "" {
+			fieldPath = path + "." + f.key
+		}
+		var err error
+		if f.group, err = g.collect(typeName+f.name, fieldPath, f.nodes); err != nil {
+			return nil, err
+		}
+	}
+	return grp, nil
+}
+
+// named returns the named capture groups nested in node, looking through unnamed groups, unnamed is set if there are any.
+func named(node *subexpnames.MatchValue, unnamed *bool) []*subexpnames.MatchValue {
+	var nodes []*subexpnames.MatchValue
+	for _, inner := range node.Nested {
+		if inner.Key == subexpnames.RootKey {
+			*unnamed = true
+			nodes = append(nodes, named(inner, new(bool))...)
+			continue
+		}
+		nodes = append(nodes, inner)
+	}
+	return nodes
+}
+
+// fieldName returns the name of the field for a capture group, key in CamelCase, for example first_name becomes FirstName.
+func fieldName(key string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(key, "_") {
+		if part == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "Group" + name
+	}
+	return name
+}
+
+// funcName returns the name of a function for the struct type typeName, exported if the type is.
+func funcName(prefix, typeName string) string {
+	if token.IsExported(typeName) {
+		return strings.ToUpper(prefix[:1]) + prefix[1:] + typeName
+	}
+	return prefix + strings.ToUpper(typeName[:1]) + typeName[1:]
+}
+
+// literal returns a Go string literal for s, a raw string literal if possible.
+func literal(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// declaredNames returns the names of the variable holding the Pattern and of the functions parsing the struct type typeName.
+func declaredNames(typeName string) (patternName, parseName, parseAllName string) {
+	return strings.ToLower(typeName[:1]) + typeName[1:] + "Pattern", funcName("parse", typeName), funcName("parseAll", typeName)
+}
+
+// write writes the unformatted source of the file.
+func (g *generator) write(pkg, typeName, expr string) {
+	patternName, parseName, parseAllName := declaredNames(typeName)
+
+	g.printf("// Code generated by subexpnames-gen; DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg)
+	g.printf("import \"github.com/thetechpanda/subexpnames\"\n\n")
+	g.printf("// %s is the Pattern %s values are parsed with.\n", patternName, typeName)
+	g.printf("var %s = subexpnames.MustCompile(%s)\n\n", patternName, literal(expr))
+
+	g.printf("// %s returns the leftmost match of %s in subject, it returns false if subject does not match.\n", parseName, patternName)
+	g.printf("func %s(subject string) (%s, bool) {\n", parseName, typeName)
+	g.printf("var v %s\n", typeName)
+	g.printf("mv, ok := %s.MatchFirst(subject)\n", patternName)
+	g.printf("if !ok {\nreturn v, false\n}\n")
+	g.printf("v.fill(mv)\nreturn v, true\n}\n\n")
+
+	g.printf("// %s returns every match of %s in subject, it returns nil if subject does not match.\n", parseAllName, patternName)
+	g.printf("func %s(subject string) []%s {\n", parseAllName, typeName)
+	g.printf("matches, ok := %s.Match(subject)\n", patternName)
+	g.printf("if !ok {\nreturn nil\n}\n")
+	g.printf("values := make([]%s, len(*matches))\n", typeName)
+	g.printf("for i, mv := range *matches {\nvalues[i].fill(mv)\n}\n")
+	g.printf("return values\n}\n")
+
+	for _, grp := range g.groups {
+		g.writeGroup(grp, patternName)
+	}
+}
+
+// writeGroup writes the struct type of grp and its fill methods.
+func (g *generator) writeGroup(grp *group, patternName string) {
+	g.printf("\n")
+	if grp.path == "" {
+		g.printf("// %s holds a match of %s.\n", grp.typeName, patternName)
+	} else {
+		g.printf("// %s holds a match of the capture group %s of %s.\n", grp.typeName, grp.path, patternName)
+	}
+	g.printf("type %s struct {\n", grp.typeName)
+	if grp.path == "" {
+		g.printf("// Value is the text of the match.\n")
+	} else {
+		g.printf("// Value is the text matched by the capture group.\n")
+	}
+	g.printf("Value string\n")
+	for _, f := range grp.fields {
+		typ := "string"
+		if f.group != nil {
+			typ = f.group.typeName
+		}
+		if f.repeated {
+			g.printf("// %s holds the values of the capture groups named %s.\n", f.name, f.key)
+			typ = "[]" + typ
+		} else {
+			g.printf("// %s holds the value of the capture group named %s.\n", f.name, f.key)
+		}
+		g.printf("%s %s\n", f.name, typ)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// fill fills v from the tree rooted at mv.\n")
+	g.printf("func (v *%s) fill(mv *subexpnames.MatchValue) {\n", grp.typeName)
+	g.printf("v.Value = mv.Value\nv.fillNested(mv)\n}\n\n")
+
+	g.printf("// fillNested fills the fields of v from the capture groups nested in mv.\n")
+	g.printf("func (v *%s) fillNested(mv *subexpnames.MatchValue) {\n", grp.typeName)
+	if len(grp.fields) == 0 && !grp.unnamed {
+		g.printf("}\n")
+		return
+	}
+	g.printf("for _, inner := range mv.Nested {\n")
+	g.printf("if !inner.Matched {\ncontinue\n}\n")
+	g.printf("switch inner.Key {\n")
+	for _, f := range grp.fields {
+		g.printf("case %s:\n", strconv.Quote(f.key))
+		switch {
+		case f.group == nil && f.repeated:
+			g.printf("v.%s = append(v.%[1]s, inner.Value)\n", f.name)
+		case f.group == nil:
+			g.printf("v.%s = inner.Value\n", f.name)
+		case f.repeated:
+			g.printf("v.%s = append(v.%[1]s, %s{})\n", f.name, f.group.typeName)
+			g.printf("v.%s[len(v.%[1]s)-1].fill(inner)\n", f.name)
+		default:
+			g.printf("v.%s.fill(inner)\n", f.name)
+		}
+	}
+	if grp.unnamed {
+		g.printf("case subexpnames.RootKey:\n")
+		g.printf("v.fillNested(inner)\n")
+	}
+	g.printf("}\n}\n}\n")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const datePattern = `(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))(?:(T(?P<time>(?P<hour>\d\d)(?::(?P<minute>\d\d))?))|(?:@(?P<tag>\w+))+)?`
+
+const program = `package main
+
+import "fmt"
+
+func main() {
+	for _, rec := range ParseAllRecord("from 2016-01-02T10:30 to 1234-56-78@a@b and 2020-02-03T09") {
+		fmt.Println(rec.Value, rec.Overlap[0].Year.Tens, rec.Overlap[0].Month.Value, rec.Overlap[1].Day.Ones, len(rec.Overlap), rec.Time.Hour, rec.Time.Minute, rec.Tag)
+	}
+	rec, ok := ParseRecord("no date")
+	fmt.Println(rec.Value == "", ok)
+}
+`
+
+const expected = `2016-01-02T10:30 1 01 2 2 10 30 
+1234-56-78@a@b 3 56 8 2   b
+2020-02-03T09 2 02 3 2 09  
+true false
+`
+
+// TestGenerate builds and runs a program using the generated code.
+func TestGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the build of the generated code in short mode")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	src, err := generate("main", "Record", datePattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/gen\n\ngo 1.23.0\n\nrequire github.com/thetechpanda/subexpnames v0.0.0\n\nreplace github.com/thetechpanda/subexpnames => " + root + "\n",
+		"main.go":          program,
+		"record_subexp.go": string(src),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s\n%s", err, out, src)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestGenerateTypes(t *testing.T) {
+	src, err := generate("dates", "record", datePattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range []string{
+		"func parseRecord(subject string) (record, bool)",
+		"func parseAllRecord(subject string) []record",
+		"Overlap []recordOverlap\n",
+		"Year recordOverlapYear\n",
+		"Tens string\n",
+		"Time recordTime\n",
+		"Tag string\n",
+		"case subexpnames.RootKey:",
+	} {
+		if !strings.Contains(string(src), decl) {
+			t.Errorf("expected %q in the generated code", decl)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		pkg, typeName, pattern string
+		err                    string
+	}{
+		{"main", "Record", `(?P<a`, "invalid named capture"},
+		{"main", "1Record", `(?P<a>x)`, "invalid type name"},
+		{"my-pkg", "Record", `(?P<a>x)`, "invalid package name"},
+		{"main", "Record", `(?P<first_name>x)(?P<firstName>y)`, "same field name FirstName"},
+		{"main", "Record", `(?P<value>x)`, "field name Value"},
+		{"main", "Record", `(?P<a>(?P<b>(?P<z>x)))(?P<a_b>(?P<c>y))`, "type name RecordAB is already used"},
+		{"main", "record", `(?P<pattern>(?P<x>a))`, "type name recordPattern is already used"},
+		{"main", "parse", `(?P<parse>(?P<x>a))`, "type name parseParse is already used"},
+	}
+	for _, test := range tests {
+		_, err := generate(test.pkg, test.typeName, test.pattern)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.pattern, test.err, err)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for key, name := range map[string]string{
+		"year":       "Year",
+		"first_name": "FirstName",
+		"_x__y_":     "XY",
+		"_":          "Group",
+		"1st":        "Group1st",
+	} {
+		if got := fieldName(key); got != name {
+			t.Errorf("%s: expected %s, got %s", key, name, got)
+		}
+	}
+}
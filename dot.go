@@ -0,0 +1,59 @@
+package subexpnames
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dotEscaper escapes the characters that have a meaning in the quoted strings of the DOT language.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// dotLabel returns the label of the node of mv: its key, its quoted value and its offsets on separate lines.
+func dotLabel(mv *MatchValue, root bool) string {
+	key := mv.Key
+	switch {
+	case root:
+		key = "(root)"
+	case key == RootKey:
+		key = "(unnamed)"
+	}
+	if !mv.Matched {
+		return key + `\n(unmatched)`
+	}
+	return key + `\n` + dotEscaper.Replace(strconv.Quote(mv.Value)) + fmt.Sprintf(`\n[%d:%d]`, mv.start, mv.end)
+}
+
+// writeDOT writes the node of mv, its nested capture groups and the edges between them, the nodes of group are named g<group>n<n>.
+// next is the number of the next node, it returns the number of the node after the last one written.
+func (tw *treeWriter) writeDOT(mv *MatchValue, group, next int, root bool) int {
+	id := next
+	style := ""
+	if !mv.Matched {
+		style = ", style=dashed"
+	}
+	tw.writeString(fmt.Sprintf("\t\tg%dn%d [label=\"%s\"%s];\n", group, id, dotLabel(mv, root), style))
+	next++
+	for _, inner := range mv.Nested {
+		tw.writeString(fmt.Sprintf("\t\tg%dn%d -> g%dn%d;\n", group, id, group, next))
+		next = tw.writeDOT(inner, group, next, false)
+	}
+	return next
+}
+
+// WriteDOT writes the matches to w as a graph in the DOT language of Graphviz, for example to render it with dot -Tsvg.
+// Each match is a cluster labelled with its index, each capture group is a node labelled with its key, its quoted value and its byte offsets,
+// and each capture group has an edge to each of its nested capture groups. Capture groups that did not take part in the match are dashed.
+// It returns the first error returned by w.
+func WriteDOT(w io.Writer, m *Matches) error {
+	tw := newTreeWriter(w, nil)
+	tw.writeString("digraph matches {\n\tnode [shape=box];\n")
+	for i, mv := range *m {
+		tw.writeString(fmt.Sprintf("\tsubgraph cluster_%d {\n\t\tlabel=\"group %d\";\n", i, i))
+		tw.writeDOT(mv, i, 0, true)
+		tw.writeString("\t}\n")
+	}
+	tw.writeString("}\n")
+	return tw.err
+}
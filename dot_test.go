@@ -0,0 +1,58 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestWriteDOT(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<pair>(?P<key>\w+)=(?P<value>"[^"]*"))(?:;(\d))?`)
+	matches, ok := subexpnames.Match(regex, `a="x\y";1 b=""`)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	var b strings.Builder
+	if err := subexpnames.WriteDOT(&b, matches); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph matches {
+	node [shape=box];
+	subgraph cluster_0 {
+		label="group 0";
+		g0n0 [label="(root)\n\"a=\\\"x\\\\y\\\";1\"\n[0:9]"];
+		g0n0 -> g0n1;
+		g0n1 [label="pair\n\"a=\\\"x\\\\y\\\"\"\n[0:7]"];
+		g0n1 -> g0n2;
+		g0n2 [label="key\n\"a\"\n[0:1]"];
+		g0n1 -> g0n3;
+		g0n3 [label="value\n\"\\\"x\\\\y\\\"\"\n[2:7]"];
+		g0n0 -> g0n4;
+		g0n4 [label="(unnamed)\n\"1\"\n[8:9]"];
+	}
+	subgraph cluster_1 {
+		label="group 1";
+		g1n0 [label="(root)\n\"b=\\\"\\\"\"\n[10:14]"];
+		g1n0 -> g1n1;
+		g1n1 [label="pair\n\"b=\\\"\\\"\"\n[10:14]"];
+		g1n1 -> g1n2;
+		g1n2 [label="key\n\"b\"\n[10:11]"];
+		g1n1 -> g1n3;
+		g1n3 [label="value\n\"\\\"\\\"\"\n[12:14]"];
+		g1n0 -> g1n4;
+		g1n4 [label="(unnamed)\n(unmatched)", style=dashed];
+	}
+}
+`
+	if b.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	failure := errors.New("failure")
+	if err := subexpnames.WriteDOT(failingWriter{failure}, matches); err != failure {
+		t.Fatalf("expected the error of the writer, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package subexpnames
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNoMatch is returned when the subject does not match the regular expression.
+	ErrNoMatch = errors.New("subexpnames: no match")
+	// ErrNotFound is the reason of a ValueError when the path does not select any capture group that took part in the match.
+	// errors.Is reports a *KeyNotFoundError as ErrNotFound as well.
+	ErrNotFound = errors.New("subexpnames: no matched capture group")
+)
+
+// GroupOutOfRangeError describes a group index outside of the matches found.
+type GroupOutOfRangeError struct {
+	// Group is the index that was requested.
+	Group int
+	// Len is the number of matches found, see Matches.Len.
+	Len int
+}
+
+// Error implements the error interface.
+func (e *GroupOutOfRangeError) Error() string {
+	return fmt.Sprintf("subexpnames: group index %d out of range [0:%d]", e.Group, e.Len)
+}
+
+// KeyNotFoundError describes keys that do not identify any capture group of a match.
+type KeyNotFoundError struct {
+	// Group is the index of the match the keys were looked up in.
+	Group int
+	// Path is the keys that were looked up, separated by dots.
+	Path string
+	// ClosestMatch is the path of the capture group of the match whose keys are the closest to Path, empty if the match has no capture groups.
+	// It is empty as well when Unmatched is set.
+	ClosestMatch string
+	// Unmatched is set when the keys identify capture groups but none of them took part in the match, the error is then returned only by lookups skipping them, such as GetMatchedErr.
+	Unmatched bool
+}
+
+// Error implements the error interface.
+func (e *KeyNotFoundError) Error() string {
+	if e.Unmatched {
+		return fmt.Sprintf("subexpnames: key path %q did not take part in group %d", e.Path, e.Group)
+	}
+	if e.ClosestMatch == "" {
+		return fmt.Sprintf("subexpnames: key path %q not found in group %d", e.Path, e.Group)
+	}
+	return fmt.Sprintf("subexpnames: key path %q not found in group %d, closest match is %q", e.Path, e.Group, e.ClosestMatch)
+}
+
+// Is reports whether target is ErrNotFound.
+func (e *KeyNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ValueIndexError describes a value index outside of the values found for the keys.
+type ValueIndexError struct {
+	// Group is the index of the match the keys were looked up in.
+	Group int
+	// Path is the keys that were looked up, separated by dots.
+	Path string
+	// Index is the value index that was requested.
+	Index int
+	// Len is the number of values found.
+	Len int
+}
+
+// Error implements the error interface.
+func (e *ValueIndexError) Error() string {
+	return fmt.Sprintf("subexpnames: value index %d out of range [0:%d] for key path %q in group %d", e.Index, e.Len, e.Path, e.Group)
+}
+
+// MatchErr is like Match but returns ErrNoMatch if the subject string does not match.
+// Like Match, it parses the regular expression on every call, see Pattern.MatchErr.
+func MatchErr(regexp *regexp.Regexp, subject string) (*Matches, error) {
+	return NewPattern(regexp).MatchErr(subject)
+}
+
+// MatchErr is like Match but returns ErrNoMatch if the subject string does not match.
+func (p *Pattern) MatchErr(subject string) (*Matches, error) {
+	matches, ok := p.Match(subject)
+	if !ok {
+		return nil, ErrNoMatch
+	}
+	return matches, nil
+}
+
+// lookup returns the matchValues identified by keys in the specified match, see descend, or an error describing why there are none.
+func (rm *Matches) lookup(group int, matchedOnly bool, keys []string) ([]*MatchValue, error) {
+	if group < 0 || group >= len(*rm) {
+		return nil, &GroupOutOfRangeError{Group: group, Len: len(*rm)}
+	}
+	nodes := descend((*rm)[group], matchedOnly, keys...)
+	if len(nodes) > 0 {
+		return nodes, nil
+	}
+	err := &KeyNotFoundError{Group: group, Path: strings.Join(keys, ".")}
+	if matchedOnly && len(descend((*rm)[group], false, keys...)) > 0 {
+		err.Unmatched = true
+		return nil, err
+	}
+	distance := -1
+	for _, path := range rm.Keys(group) {
+		candidate := strings.Join(path, ".")
+		if d := editDistance(err.Path, candidate); distance < 0 || d < distance {
+			err.ClosestMatch, distance = candidate, d
+		}
+	}
+	return nil, err
+}
+
+// editDistance returns the Levenshtein distance between a and b, the number of single byte edits that turn a into b.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			diagonal, row[j] = row[j], min(row[j]+1, row[j-1]+1, diagonal+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+// GetAllErr is like GetAll but returns an error describing why no value is found:
+// a *GroupOutOfRangeError if the match is not found and a *KeyNotFoundError if the keys are not.
+func (rm *Matches) GetAllErr(group int, keys ...string) ([]string, error) {
+	nodes, err := rm.lookup(group, false, keys)
+	if err != nil {
+		return nil, err
+	}
+	return valuesOf(nodes), nil
+}
+
+// GetAllMatchedErr is like GetAllMatched but returns an error describing why no value is found, see GetAllErr.
+// If the keys identify capture groups that did not take part in the match, the *KeyNotFoundError has Unmatched set.
+func (rm *Matches) GetAllMatchedErr(group int, keys ...string) ([]string, error) {
+	nodes, err := rm.lookup(group, true, keys)
+	if err != nil {
+		return nil, err
+	}
+	return valuesOf(nodes), nil
+}
+
+// GetErr is like Get but returns an error describing why the value is not found, see GetAllErr.
+// It returns a *ValueIndexError if the value index is out of range.
+func (rm *Matches) GetErr(group int, value int, keys ...string) (string, error) {
+	values, err := rm.GetAllErr(group, keys...)
+	if err != nil {
+		return "", err
+	}
+	return valueAt(values, group, value, keys)
+}
+
+// GetMatchedErr is like GetMatched but returns an error describing why the value is not found, see GetAllMatchedErr and GetErr.
+func (rm *Matches) GetMatchedErr(group int, value int, keys ...string) (string, error) {
+	values, err := rm.GetAllMatchedErr(group, keys...)
+	if err != nil {
+		return "", err
+	}
+	return valueAt(values, group, value, keys)
+}
+
+// valueAt returns the value-th of the values found for keys in the specified match, or a *ValueIndexError.
+func valueAt(values []string, group, value int, keys []string) (string, error) {
+	if value < 0 || value >= len(values) {
+		return "", &ValueIndexError{Group: group, Path: strings.Join(keys, "."), Index: value, Len: len(values)}
+	}
+	return values[value], nil
+}
+
+// GetFirstValueOfGroupErr is like GetFirstValueOfGroup but returns an error describing why the value is not found, see GetAllErr.
+func (rm *Matches) GetFirstValueOfGroupErr(group int, keys ...string) (string, error) {
+	return rm.GetErr(group, 0, keys...)
+}
+
+// GetGroupErr is like GetGroup but returns a *GroupOutOfRangeError if the index is out of bounds.
+func (rm *Matches) GetGroupErr(group int) (*MatchValue, error) {
+	if group < 0 || group >= len(*rm) {
+		return nil, &GroupOutOfRangeError{Group: group, Len: len(*rm)}
+	}
+	return (*rm)[group], nil
+}
+
+// KeysErr is like Keys but returns a *GroupOutOfRangeError if the index is out of bounds, a match without capture groups has no keys and no error.
+func (rm *Matches) KeysErr(group int) ([][]string, error) {
+	if group < 0 || group >= len(*rm) {
+		return nil, &GroupOutOfRangeError{Group: group, Len: len(*rm)}
+	}
+	return rm.Keys(group), nil
+}
+
+// UnmatchedErr is like Unmatched but returns a *GroupOutOfRangeError if the index is out of bounds, a match whose capture groups all took part in it has no keys and no error.
+func (rm *Matches) UnmatchedErr(group int) ([][]string, error) {
+	if group < 0 || group >= len(*rm) {
+		return nil, &GroupOutOfRangeError{Group: group, Len: len(*rm)}
+	}
+	return rm.Unmatched(group), nil
+}
+
+// OffsetsErr is like Offsets but returns an error describing why no offset is found, see GetAllErr.
+func (rm *Matches) OffsetsErr(group int, keys ...string) ([][2]int, error) {
+	nodes, err := rm.lookup(group, false, keys)
+	if err != nil {
+		return nil, err
+	}
+	return offsetsOf(nodes, (*MatchValue).Span), nil
+}
+
+// RuneOffsetsErr is like RuneOffsets but returns an error describing why no offset is found, see GetAllErr.
+func (rm *Matches) RuneOffsetsErr(group int, keys ...string) ([][2]int, error) {
+	nodes, err := rm.lookup(group, false, keys)
+	if err != nil {
+		return nil, err
+	}
+	return offsetsOf(nodes, (*MatchValue).RuneSpan), nil
+}
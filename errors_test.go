@@ -0,0 +1,135 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestMatchErr(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<year>\d{4})`)
+	if _, err := subexpnames.MatchErr(regex, "no year"); !errors.Is(err, subexpnames.ErrNoMatch) {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+	matches, err := subexpnames.MatchErr(regex, "2016 and 1234")
+	if err != nil || matches.Len() != 2 {
+		t.Fatalf("expected 2 matches, got %v", err)
+	}
+	if _, err := subexpnames.MustCompile(`x`).MatchErr("y"); err != subexpnames.ErrNoMatch {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestLookupErrors(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?`)
+	matches, err := subexpnames.MatchErr(regex, "2016-01 2016-01-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := matches.GetAllErr(1, "overlap")
+	if err != nil || !slices.Equal(values, []string{"2016-01", "02"}) {
+		t.Fatalf("expected [2016-01 02], got %v %v", values, err)
+	}
+	if v, err := matches.GetErr(1, 0, "overlap", "day"); err != nil || v != "02" {
+		t.Fatalf("expected 02, got %q %v", v, err)
+	}
+	if v, err := matches.GetFirstValueOfGroupErr(0, "overlap", "month"); err != nil || v != "01" {
+		t.Fatalf("expected 01, got %q %v", v, err)
+	}
+	if mv, err := matches.GetGroupErr(1); err != nil || mv.Value != "2016-01-02" {
+		t.Fatalf("expected 2016-01-02, got %v", err)
+	}
+	if offsets, err := matches.OffsetsErr(1, "overlap", "year"); err != nil || !slices.Equal(offsets, [][2]int{{8, 12}}) {
+		t.Fatalf("expected [[8 12]], got %v %v", offsets, err)
+	}
+	if offsets, err := matches.RuneOffsetsErr(1, "overlap", "year"); err != nil || !slices.Equal(offsets, [][2]int{{8, 12}}) {
+		t.Fatalf("expected [[8 12]], got %v %v", offsets, err)
+	}
+
+	if keys, err := matches.KeysErr(0); err != nil || len(keys) != 4 {
+		t.Fatalf("expected 4 keys, got %v %v", keys, err)
+	}
+	if keys, err := matches.UnmatchedErr(0); err != nil || !slices.EqualFunc(keys, [][]string{{"overlap"}, {"overlap", "day"}}, slices.Equal) {
+		t.Fatalf("expected [[overlap] [overlap day]], got %v %v", keys, err)
+	}
+	if keys, err := matches.UnmatchedErr(1); err != nil || keys != nil {
+		t.Fatalf("expected no keys, got %v %v", keys, err)
+	}
+
+	var rangeErr *subexpnames.GroupOutOfRangeError
+	if _, err := matches.GetGroupErr(2); !errors.As(err, &rangeErr) || rangeErr.Group != 2 || rangeErr.Len != 2 {
+		t.Fatalf("expected a *GroupOutOfRangeError, got %v", err)
+	}
+	for _, err := range []error{
+		func() error { _, err := matches.GetAllErr(-1, "overlap"); return err }(),
+		func() error { _, err := matches.GetErr(2, 0, "overlap"); return err }(),
+		func() error { _, err := matches.KeysErr(2); return err }(),
+		func() error { _, err := matches.UnmatchedErr(-1); return err }(),
+		func() error { _, err := matches.OffsetsErr(5); return err }(),
+	} {
+		if !errors.As(err, &rangeErr) {
+			t.Fatalf("expected a *GroupOutOfRangeError, got %v", err)
+		}
+	}
+	if expected := "subexpnames: group index 5 out of range [0:2]"; rangeErr.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, rangeErr)
+	}
+
+	var keyErr *subexpnames.KeyNotFoundError
+	_, err = matches.GetErr(0, 0, "overlap", "yaer")
+	if !errors.As(err, &keyErr) || keyErr.Group != 0 || keyErr.Path != "overlap.yaer" || keyErr.ClosestMatch != "overlap.year" || keyErr.Unmatched {
+		t.Fatalf("expected a *KeyNotFoundError suggesting overlap.year, got %v", err)
+	}
+	if !errors.Is(err, subexpnames.ErrNotFound) {
+		t.Fatalf("expected the error to be ErrNotFound")
+	}
+	expected := `subexpnames: key path "overlap.yaer" not found in group 0, closest match is "overlap.year"`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+
+	// the second overlap and its day do not take part in the first match
+	if v, err := matches.GetErr(0, 1, "overlap"); err != nil || v != "" {
+		t.Fatalf("expected an empty value, got %q %v", v, err)
+	}
+	_, err = matches.GetMatchedErr(0, 0, "overlap", "day")
+	if !errors.As(err, &keyErr) || !keyErr.Unmatched || keyErr.ClosestMatch != "" {
+		t.Fatalf("expected an unmatched *KeyNotFoundError, got %v", err)
+	}
+	expected = `subexpnames: key path "overlap.day" did not take part in group 0`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+	if values, err := matches.GetAllMatchedErr(0, "overlap"); err != nil || !slices.Equal(values, []string{"2016-01"}) {
+		t.Fatalf("expected [2016-01], got %v %v", values, err)
+	}
+
+	var indexErr *subexpnames.ValueIndexError
+	_, err = matches.GetMatchedErr(0, 1, "overlap")
+	if !errors.As(err, &indexErr) || indexErr.Group != 0 || indexErr.Path != "overlap" || indexErr.Index != 1 || indexErr.Len != 1 {
+		t.Fatalf("expected a *ValueIndexError, got %v", err)
+	}
+	expected = `subexpnames: value index 1 out of range [0:1] for key path "overlap" in group 0`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+	if _, err := matches.GetErr(0, -1, "overlap"); !errors.As(err, &indexErr) {
+		t.Fatalf("expected a *ValueIndexError, got %v", err)
+	}
+
+	// a match without capture groups has nothing to suggest
+	matches, _ = subexpnames.MatchErr(regexp.MustCompile(`\d`), "1")
+	_, err = matches.GetAllErr(0, "digit")
+	if !errors.As(err, &keyErr) || keyErr.ClosestMatch != "" || err.Error() != `subexpnames: key path "digit" not found in group 0` {
+		t.Fatalf("expected a *KeyNotFoundError without suggestion, got %v", err)
+	}
+
+	// the typed accessors report a missing match the same way
+	if _, err := matches.Int(1, ""); !errors.As(err, &rangeErr) {
+		t.Fatalf("expected a *GroupOutOfRangeError, got %v", err)
+	}
+}
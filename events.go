@@ -0,0 +1,58 @@
+package subexpnames
+
+import "regexp"
+
+// Handler receives the events of MatchEvents, they describe the trees Match would build, in the order Walk visits them.
+type Handler interface {
+	// OnMatchStart is called before the events of a match, index is the index of the match, as in Matches.
+	OnMatchStart(index int)
+	// OnEnter is called for each capture group of a match, before the capture groups nested in it, the whole match first with RootKey.
+	// start and end are the byte offsets of value in the subject, they are -1 if the capture group did not take part in the match.
+	OnEnter(key, value string, start, end int)
+	// OnExit is called for each capture group of a match, after the capture groups nested in it.
+	OnExit(key string)
+	// OnMatchEnd is called after the events of a match.
+	OnMatchEnd(index int)
+}
+
+// MatchEvents matches the regular expression against the subject string and reports each match to the handler as events, see Pattern.MatchEvents.
+// Like Match, it parses the regular expression on every call.
+func MatchEvents(re *regexp.Regexp, subject string, handler Handler) bool {
+	return NewPattern(re).MatchEvents(subject, handler)
+}
+
+// MatchEvents matches the Pattern against the subject string and reports each match to the handler as events, without building any tree.
+// For each match, in order, the handler's OnMatchStart is called, then OnEnter and OnExit for each capture group as a walk of the tree of the match would visit it,
+// and OnMatchEnd. Capture groups that did not take part in the match are reported as well.
+// Values are sliced from the subject, so the only memory allocated is that of the search for the matches.
+// Matches are searched one at a time as MatchIter does, it returns false if the subject does not match.
+func (p *Pattern) MatchEvents(subject string, handler Handler) bool {
+	// open holds the capture groups entered and not yet exited, innermost last.
+	open := make([]int, 0, len(p.names))
+	found := false
+	p.eachIndexes(subject, func(i int, indexes []int) bool {
+		found = true
+		handler.OnMatchStart(i)
+		// capture groups come in pre-order, so a capture group is entered once the open capture groups not enclosing it are exited.
+		for j, name := range p.names {
+			for len(open) > 0 && open[len(open)-1] != p.parents[j] {
+				handler.OnExit(p.names[open[len(open)-1]])
+				open = open[:len(open)-1]
+			}
+			start, end := indexes[2*j], indexes[2*j+1]
+			value := ""
+			if start >= 0 {
+				value = subject[start:end]
+			}
+			handler.OnEnter(name, value, start, end)
+			open = append(open, j)
+		}
+		for len(open) > 0 {
+			handler.OnExit(p.names[open[len(open)-1]])
+			open = open[:len(open)-1]
+		}
+		handler.OnMatchEnd(i)
+		return true
+	})
+	return found
+}
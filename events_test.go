@@ -0,0 +1,106 @@
+package subexpnames_test
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+// recorder is a Handler recording the events it receives.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) OnMatchStart(index int) {
+	r.events = append(r.events, fmt.Sprintf("start %d", index))
+}
+
+func (r *recorder) OnEnter(key, value string, start, end int) {
+	r.events = append(r.events, fmt.Sprintf("<%s %q %d:%d", key, value, start, end))
+}
+
+func (r *recorder) OnExit(key string) {
+	r.events = append(r.events, key+">")
+}
+
+func (r *recorder) OnMatchEnd(index int) {
+	r.events = append(r.events, fmt.Sprintf("end %d", index))
+}
+
+func TestMatchEvents(t *testing.T) {
+	tests := []struct {
+		expr, subject string
+	}{
+		{`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`, "2016-01-02 and 1234-56-78"},
+		{`(?P<a>(?P<b>(?P<c>x)?)y)(?P<d>z)?`, "xyz y"},
+		{`\b(?P<word>\w+)`, "ab cd"},
+		{logPattern, logSubject(1 << 10)},
+	}
+	for _, test := range tests {
+		re := regexp.MustCompile(test.expr)
+		// the events expected are those of a walk of the trees built by Match
+		var expected []string
+		matches, ok := subexpnames.Match(re, test.subject)
+		for i, mv := range matches.All() {
+			expected = append(expected, fmt.Sprintf("start %d", i))
+			subexpnames.WalkPrePost(mv, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+				expected = append(expected, fmt.Sprintf("<%s %q %d:%d", node.Key, node.Value, node.Start(), node.End()))
+				return subexpnames.Continue
+			}, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+				expected = append(expected, node.Key+">")
+				return subexpnames.Continue
+			})
+			expected = append(expected, fmt.Sprintf("end %d", i))
+		}
+
+		r := &recorder{}
+		if found := subexpnames.MatchEvents(re, test.subject, r); found != ok {
+			t.Fatalf("%s: expected %t, got %t", test.expr, ok, found)
+		}
+		if !slices.Equal(r.events, expected) {
+			t.Fatalf("%s: expected %v, got %v", test.expr, expected, r.events)
+		}
+	}
+
+	r := &recorder{}
+	if subexpnames.MatchEvents(regexp.MustCompile(`(?P<x>x)`), "abc", r) || len(r.events) != 0 {
+		t.Fatalf("expected no events, got %v", r.events)
+	}
+}
+
+// discard is a Handler ignoring the events it receives.
+type discard struct{}
+
+func (discard) OnMatchStart(index int)                    {}
+func (discard) OnEnter(key, value string, start, end int) {}
+func (discard) OnExit(key string)                         {}
+func (discard) OnMatchEnd(index int)                      {}
+
+func TestMatchEventsAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 12)
+	matches, _ := p.Match(subject)
+	// the search allocates the indexes of each match, the events allocate nothing else
+	allocs := testing.AllocsPerRun(10, func() {
+		p.MatchEvents(subject, discard{})
+	})
+	if max := float64(matches.Len() + 1); allocs > max {
+		t.Fatalf("expected at most %.0f allocations, got %.0f", max, allocs)
+	}
+}
+
+func BenchmarkMatchEvents(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.MatchEvents(subject, discard{})
+	}
+}
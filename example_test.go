@@ -3,6 +3,7 @@ package subexpnames_test
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/thetechpanda/subexpnames"
 )
@@ -55,3 +56,130 @@ func ExampleMatch() {
 		fmt.Println(keys, "=", v) // prints [overlap year ones] = 4
 	}
 }
+
+func ExampleCompile() {
+	// compile the pattern once, the hierarchy of its groups is reused on every match
+	p := subexpnames.MustCompile(`(?P<date>(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2}))`)
+
+	for _, line := range []string{"released on 2016-01-02", "patched on 2017-03-04"} {
+		match, ok := p.Match(line)
+		if !ok {
+			continue
+		}
+		if v, ok := match.Get(0, 0, "date", "year"); ok {
+			fmt.Println(v)
+		}
+	}
+	// Output:
+	// 2016
+	// 2017
+}
+
+func ExampleScanner() {
+	// a record can span multiple lines, continuation lines start with a space
+	p := subexpnames.MustCompile(`(?P<record>(?P<level>[A-Z]+): (?P<message>[^\n]*(?:\n [^\n]*)*))`)
+	input := strings.NewReader("INFO: started\nERROR: failed\n  at main.go:10\n  at run.go:20\nINFO: stopped\n")
+
+	s := p.Scanner(input)
+	for s.Scan() {
+		match := s.Match()
+		fmt.Printf("%d: %q\n", match.Start(), match.Nested[0].Nested[0].Value)
+	}
+	if err := s.Err(); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// 0: "INFO"
+	// 14: "ERROR"
+	// 59: "INFO"
+}
+
+func ExampleMatchValue_Query() {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	match, ok := subexpnames.MatchFirst(re, "this is a test subject to see if we can parse 2016-01-02 using the Match() function.")
+	if !ok {
+		return
+	}
+
+	for _, path := range []string{"overlap.year.tens", "overlap[1].day", "**.tens", "overlap.*"} {
+		nodes, err := match.Query(path)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var values []string
+		for _, node := range nodes {
+			values = append(values, node.Key+"="+node.Value)
+		}
+		fmt.Println(path, values)
+	}
+	// Output:
+	// overlap.year.tens [tens=1]
+	// overlap[1].day [day=02]
+	// **.tens [tens=1 tens=0 tens=0]
+	// overlap.* [year=2016 month=01 day=02]
+}
+
+func ExampleUnmarshal() {
+	type Digits struct {
+		Tens int `subexp:"tens"`
+		Ones int `subexp:"ones"`
+	}
+	type Date struct {
+		Year  int `subexp:"overlap.year"`
+		Month int `subexp:"overlap.month"`
+		// slices collect the repeated tens groups, at any depth
+		Tens []int `subexp:"**.tens"`
+		// nested structs map to nested groups
+		Day Digits `subexp:"overlap[1].day"`
+	}
+
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	match, ok := subexpnames.MatchFirst(re, "this is a test subject to see if we can parse 2016-01-02 using the Match() function.")
+	if !ok {
+		return
+	}
+
+	var date Date
+	if err := subexpnames.Unmarshal(match, &date); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%+v\n", date)
+	// Output:
+	// {Year:2016 Month:1 Tens:[1 0 0] Day:{Tens:0 Ones:2}}
+}
+
+func ExampleNewParser() {
+	type Entry struct {
+		Year    int    `subexp:"date.year"`
+		Month   int    `subexp:"date.month"`
+		Level   string `subexp:"level"`
+		Message string `subexp:"message"`
+	}
+	re := regexp.MustCompile(`(?P<date>(?P<year>\d{4})-(?P<month>\d{2})) (?P<level>[A-Z]+) (?P<message>.*)`)
+
+	// the tags are checked against the regular expression once
+	parser, err := subexpnames.NewParser[Entry](re)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	entry, err := parser.Parse("2016-01 INFO service started")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%+v\n", entry)
+
+	// a misspelled tag is reported when the parser is created
+	type Misspelled struct {
+		Level string `subexp:"levle"`
+	}
+	if _, err := subexpnames.NewParser[Misspelled](re); err != nil {
+		fmt.Println("invalid tags")
+	}
+	// Output:
+	// {Year:2016 Month:1 Level:INFO Message:service started}
+	// invalid tags
+}
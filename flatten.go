@@ -0,0 +1,50 @@
+package subexpnames
+
+// flatten adds the values of the capture groups nested in mv that took part in the match to values, under their dotted path prefixed by prefix.
+func (mv *MatchValue) flatten(values map[string][]string, prefix string) {
+	for _, inner := range named(mv) {
+		if !inner.Matched {
+			continue
+		}
+		path := prefix + inner.Key
+		values[path] = append(values[path], inner.Value)
+		inner.flatten(values, path+".")
+	}
+}
+
+// Flatten returns the values of the tree rooted at mv keyed by their dotted paths, for example overlap.year.tens.
+// The values of capture groups with the same path are held in the order they appear in the tree, for example Flatten()["overlap.year"] holds the same values as Matches.GetAllMatched(group, "overlap", "year").
+// The value of mv itself is held by RootKey.
+// Capture groups that did not take part in the match are left out, unnamed capture groups are left out and their nested capture groups take their place in the paths.
+func (mv *MatchValue) Flatten() map[string][]string {
+	values := map[string][]string{RootKey: {mv.Value}}
+	mv.flatten(values, "")
+	return values
+}
+
+// Flatten is like MatchValue.Flatten but holds the values of every match, in order.
+func (rm *Matches) Flatten() map[string][]string {
+	values := make(map[string][]string)
+	for _, mv := range *rm {
+		values[RootKey] = append(values[RootKey], mv.Value)
+		mv.flatten(values, "")
+	}
+	return values
+}
+
+// ToMap returns the tree rooted at mv as nested maps, in the compact form of MarshalCompactJSON:
+// a capture group without nested named groups is a string, a capture group with nested named groups is a map[string]any holding its value under ValueKey,
+// and keys used by more than one capture group with the same parent are a []any.
+// mv itself is always returned as a map.
+func (mv *MatchValue) ToMap() map[string]any {
+	return mv.compact(true).(map[string]any)
+}
+
+// ToMap returns each match as nested maps, see MatchValue.ToMap.
+func (rm *Matches) ToMap() []map[string]any {
+	maps := make([]map[string]any, len(*rm))
+	for i, mv := range *rm {
+		maps[i] = mv.ToMap()
+	}
+	return maps
+}
@@ -0,0 +1,101 @@
+package subexpnames_test
+
+import (
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestFlatten(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<tens>\d\d)(?P<ones>\d\d))-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?(?: (\w+)=(?P<note>\w+))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 a=b;1234-56")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	mv, _ := matches.GetGroup(0)
+	expected := map[string][]string{
+		"":                  {"2016-01-02 a=b"},
+		"overlap":           {"2016-01", "02"},
+		"overlap.year":      {"2016"},
+		"overlap.year.tens": {"20"},
+		"overlap.year.ones": {"16"},
+		"overlap.month":     {"01"},
+		"overlap.day":       {"02"},
+		"note":              {"b"},
+	}
+	if flat := mv.Flatten(); !maps.EqualFunc(flat, expected, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expected, flat)
+	}
+	if values, _ := matches.GetAllMatched(0, "overlap", "year", "tens"); !slices.Equal(values, expected["overlap.year.tens"]) {
+		t.Fatalf("expected %v, got %v", expected["overlap.year.tens"], values)
+	}
+
+	// the values of the nested groups are keyed relative to the MatchValue
+	expected = map[string][]string{"": {"2016"}, "tens": {"20"}, "ones": {"16"}}
+	if flat := mv.Nested[0].Nested[0].Flatten(); !maps.EqualFunc(flat, expected, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expected, flat)
+	}
+
+	expected = map[string][]string{
+		"":                  {"2016-01-02 a=b", "1234-56"},
+		"overlap":           {"2016-01", "02", "1234-56"},
+		"overlap.year":      {"2016", "1234"},
+		"overlap.year.tens": {"20", "12"},
+		"overlap.year.ones": {"16", "34"},
+		"overlap.month":     {"01", "56"},
+		"overlap.day":       {"02"},
+		"note":              {"b"},
+	}
+	if flat := matches.Flatten(); !maps.EqualFunc(flat, expected, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expected, flat)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<tens>\d\d)(?P<ones>\d\d))-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?(?: (\w+)=(?P<note>\w+))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 a=b;1234-56")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	expected := []map[string]any{
+		{
+			subexpnames.ValueKey: "2016-01-02 a=b",
+			"overlap": []any{
+				map[string]any{
+					subexpnames.ValueKey: "2016-01",
+					"year":               map[string]any{subexpnames.ValueKey: "2016", "tens": "20", "ones": "16"},
+					"month":              "01",
+				},
+				map[string]any{subexpnames.ValueKey: "02", "day": "02"},
+			},
+			"note": "b",
+		},
+		{
+			subexpnames.ValueKey: "1234-56",
+			"overlap": []any{
+				map[string]any{
+					subexpnames.ValueKey: "1234-56",
+					"year":               map[string]any{subexpnames.ValueKey: "1234", "tens": "12", "ones": "34"},
+					"month":              "56",
+				},
+			},
+		},
+	}
+	if m := matches.ToMap(); !reflect.DeepEqual(m, expected) {
+		t.Fatalf("expected %v, got %v", expected, m)
+	}
+	mv, _ := matches.GetGroup(1)
+	if m := mv.ToMap(); !reflect.DeepEqual(m, expected[1]) {
+		t.Fatalf("expected %v, got %v", expected[1], m)
+	}
+	// a leaf is still returned as a map
+	leaf := mv.Nested[0].Nested[1]
+	if m := leaf.ToMap(); !reflect.DeepEqual(m, map[string]any{subexpnames.ValueKey: "56"}) {
+		t.Fatalf("expected the month in a map, got %v", m)
+	}
+}
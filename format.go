@@ -0,0 +1,210 @@
+package subexpnames
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// treeOptions holds the options of WriteTree.
+type treeOptions struct {
+	// maxDepth is the depth of the deepest capture groups written, negative for no limit.
+	maxDepth    int
+	offsets     bool
+	runeOffsets bool
+}
+
+// TreeOption configures how WriteTree writes a tree.
+type TreeOption func(*treeOptions)
+
+// MaxDepth limits the capture groups written by WriteTree to those nested at most depth levels under the root, the root has depth 0.
+// A capture group whose nested capture groups are not written is followed by their number. A negative depth means no limit, which is the default.
+func MaxDepth(depth int) TreeOption {
+	return func(o *treeOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// WithOffsets makes WriteTree write the byte offsets of each capture group, see MatchValue.Span.
+func WithOffsets() TreeOption {
+	return func(o *treeOptions) {
+		o.offsets = true
+	}
+}
+
+// WithRuneOffsets makes WriteTree write the rune offsets of each capture group, see MatchValue.RuneSpan.
+// The runes of the subject are counted once and shared by the trees found in it, so writing the offsets takes time linear in the length of the subject.
+func WithRuneOffsets() TreeOption {
+	return func(o *treeOptions) {
+		o.runeOffsets = true
+	}
+}
+
+// treeWriter writes trees to w, it keeps the first error returned by w.
+type treeWriter struct {
+	w    io.Writer
+	opts treeOptions
+	err  error
+}
+
+func newTreeWriter(w io.Writer, opts []TreeOption) *treeWriter {
+	tw := &treeWriter{w: w, opts: treeOptions{maxDepth: -1}}
+	for _, opt := range opts {
+		opt(&tw.opts)
+	}
+	return tw
+}
+
+func (tw *treeWriter) writeString(s string) {
+	if tw.err == nil {
+		_, tw.err = io.WriteString(tw.w, s)
+	}
+}
+
+// label returns the line written for mv: its key, its quoted value and the offsets asked for.
+// The key is left out for the root and unnamed capture groups.
+func (tw *treeWriter) label(mv *MatchValue) string {
+	var b strings.Builder
+	b.WriteString(mv.Key)
+	if mv.Key != RootKey {
+		b.WriteByte(' ')
+	}
+	if !mv.Matched {
+		b.WriteString("(unmatched)")
+		return b.String()
+	}
+	b.WriteString(strconv.Quote(mv.Value))
+	if tw.opts.offsets {
+		fmt.Fprintf(&b, " [%d:%d]", mv.start, mv.end)
+	}
+	if tw.opts.runeOffsets {
+		start, end := mv.RuneSpan()
+		fmt.Fprintf(&b, " runes [%d:%d]", start, end)
+	}
+	return b.String()
+}
+
+// write writes mv and its nested capture groups, prefix is written before the lines of the nested capture groups.
+func (tw *treeWriter) write(mv *MatchValue, prefix string, depth int) {
+	tw.writeString(tw.label(mv))
+	if len(mv.Nested) > 0 && depth == tw.opts.maxDepth {
+		tw.writeString(fmt.Sprintf(" (%d nested)", len(mv.Nested)))
+	}
+	tw.writeString("\n")
+	if depth == tw.opts.maxDepth {
+		return
+	}
+	for i, inner := range mv.Nested {
+		if i == len(mv.Nested)-1 {
+			tw.writeString(prefix + "`-- ")
+			tw.write(inner, prefix+"    ", depth+1)
+		} else {
+			tw.writeString(prefix + "|-- ")
+			tw.write(inner, prefix+"|   ", depth+1)
+		}
+	}
+}
+
+// WriteTree writes the tree rooted at mv to w as an indented ASCII tree, one line per capture group with its key and its quoted value, for example:
+//
+//	"2016-01-02"
+//	|-- overlap "2016-01"
+//	|   |-- year "2016"
+//	|   `-- month "01"
+//	`-- overlap "02"
+//	    `-- day "02"
+//
+// Capture groups that did not take part in the match are written as (unmatched), the key of the root and of unnamed capture groups is left out.
+// The options can limit the depth of the tree and add the offsets of each capture group.
+// It returns the first error returned by w.
+func (mv *MatchValue) WriteTree(w io.Writer, opts ...TreeOption) error {
+	tw := newTreeWriter(w, opts)
+	tw.write(mv, "", 0)
+	return tw.err
+}
+
+// WriteTree writes each match to w as an indented ASCII tree, see MatchValue.WriteTree, the tree of each match is preceded by its index.
+func (rm *Matches) WriteTree(w io.Writer, opts ...TreeOption) error {
+	tw := newTreeWriter(w, opts)
+	for i, mv := range *rm {
+		tw.writeString(fmt.Sprintf("group %d: ", i))
+		tw.write(mv, "", 0)
+	}
+	return tw.err
+}
+
+// writeLine writes mv and its nested capture groups on a single line to b.
+func writeLine(b *strings.Builder, mv *MatchValue) {
+	if mv.Key != RootKey {
+		b.WriteString(mv.Key)
+		b.WriteByte('=')
+	}
+	if !mv.Matched {
+		b.WriteString("unmatched")
+	} else {
+		b.WriteString(strconv.Quote(mv.Value))
+	}
+	if len(mv.Nested) == 0 {
+		return
+	}
+	b.WriteByte('(')
+	for i, inner := range mv.Nested {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		writeLine(b, inner)
+	}
+	b.WriteByte(')')
+}
+
+// plainMatchValue has the fields of MatchValue without its methods, it is used to format a MatchValue with %#v.
+type plainMatchValue MatchValue
+
+// Format implements fmt.Formatter.
+// The verbs %v and %s write the tree rooted at mv on a single line, each capture group as key="value" followed by its nested capture groups in parentheses, for example:
+//
+//	"2016-01-02"(overlap="2016-01"(year="2016" month="01") overlap="02"(day="02"))
+//
+// The flag + writes the tree as WriteTree does with the byte offsets, %#v writes the fields of the MatchValue.
+func (mv *MatchValue) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "%#v", (*plainMatchValue)(mv))
+	case mv == nil && (verb == 'v' || verb == 's'):
+		io.WriteString(f, "<nil>")
+	case (verb == 'v' || verb == 's') && f.Flag('+'):
+		mv.WriteTree(f, WithOffsets())
+	case verb == 'v' || verb == 's':
+		var b strings.Builder
+		writeLine(&b, mv)
+		io.WriteString(f, b.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*subexpnames.MatchValue)", verb)
+	}
+}
+
+// Format implements fmt.Formatter.
+// The verbs %v and %s write each match on a single line, see MatchValue.Format, separated by spaces and enclosed in square brackets.
+// The flag + writes the matches as WriteTree does with the byte offsets, %#v writes the MatchValues the Matches hold.
+func (rm *Matches) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "%#v", []*MatchValue(*rm))
+	case (verb == 'v' || verb == 's') && f.Flag('+'):
+		rm.WriteTree(f, WithOffsets())
+	case verb == 'v' || verb == 's':
+		var b strings.Builder
+		b.WriteByte('[')
+		for i, mv := range *rm {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeLine(&b, mv)
+		}
+		b.WriteByte(']')
+		io.WriteString(f, b.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*subexpnames.Matches)", verb)
+	}
+}
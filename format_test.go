@@ -0,0 +1,127 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestFormat(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?(?: (\pL+))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 é;1234-56")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	mv, _ := matches.GetGroup(0)
+
+	expected := `"2016-01-02 é"(overlap="2016-01"(year="2016" month="01") overlap="02"(day="02") "é")`
+	if s := fmt.Sprintf("%v", mv); s != expected {
+		t.Fatalf("expected %s, got %s", expected, s)
+	}
+	if s := fmt.Sprint(mv); s != expected {
+		t.Fatalf("expected %s, got %s", expected, s)
+	}
+	expected = `["2016-01-02 é"(overlap="2016-01"(year="2016" month="01") overlap="02"(day="02") "é") ` +
+		`"1234-56"(overlap="1234-56"(year="1234" month="56") overlap=unmatched(day=unmatched) unmatched)]`
+	if s := fmt.Sprintf("%s", matches); s != expected {
+		t.Fatalf("expected %s, got %s", expected, s)
+	}
+
+	expected = strings.Join([]string{
+		`"2016-01-02 é" [0:13]`,
+		`|-- overlap "2016-01" [0:7]`,
+		`|   |-- year "2016" [0:4]`,
+		"|   `-- month \"01\" [5:7]",
+		`|-- overlap "02" [8:10]`,
+		"|   `-- day \"02\" [8:10]",
+		"`-- \"é\" [11:13]",
+		``,
+	}, "\n")
+	if s := fmt.Sprintf("%+v", mv); s != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, s)
+	}
+
+	if s := fmt.Sprintf("%d", mv); s != "%!d(*subexpnames.MatchValue)" {
+		t.Fatalf("expected a bad verb, got %s", s)
+	}
+	if s := fmt.Sprintf("%d", matches); s != "%!d(*subexpnames.Matches)" {
+		t.Fatalf("expected a bad verb, got %s", s)
+	}
+	if s := fmt.Sprintf("%#v", mv.Nested[0].Nested[0]); !strings.Contains(s, `Key:"year", Value:"2016", Matched:true`) {
+		t.Fatalf("expected the fields of the MatchValue, got %s", s)
+	}
+	var nilValue *subexpnames.MatchValue
+	if s := fmt.Sprintf("%v", nilValue); s != "<nil>" {
+		t.Fatalf("expected <nil>, got %s", s)
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?(?: (\pL+))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 é;1234-56")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	var b strings.Builder
+	if err := matches.WriteTree(&b, subexpnames.MaxDepth(1), subexpnames.WithRuneOffsets()); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		`group 0: "2016-01-02 é" runes [0:12]`,
+		`|-- overlap "2016-01" runes [0:7] (2 nested)`,
+		`|-- overlap "02" runes [8:10] (1 nested)`,
+		"`-- \"é\" runes [11:12]",
+		`group 1: "1234-56" runes [13:20]`,
+		`|-- overlap "1234-56" runes [13:20] (2 nested)`,
+		`|-- overlap (unmatched) (1 nested)`,
+		"`-- (unmatched)",
+		``,
+	}, "\n")
+	if b.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	b.Reset()
+	mv, _ := matches.GetGroup(1)
+	if err := mv.Nested[0].WriteTree(&b, subexpnames.MaxDepth(0), subexpnames.WithOffsets(), subexpnames.WithRuneOffsets()); err != nil {
+		t.Fatal(err)
+	}
+	expected = "overlap \"1234-56\" [14:21] runes [13:20] (2 nested)\n"
+	if b.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, b.String())
+	}
+
+	failure := errors.New("failure")
+	if err := mv.WriteTree(failingWriter{failure}); err != failure {
+		t.Fatalf("expected the error of the writer, got %v", err)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func BenchmarkWriteTree(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// the trees are built again on every run, so their rune offsets are worked out again.
+		b.StopTimer()
+		matches, _ := p.Match(subject)
+		b.StartTimer()
+		if err := matches.WriteTree(io.Discard, subexpnames.WithRuneOffsets()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
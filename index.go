@@ -0,0 +1,178 @@
+package subexpnames
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// pathTable describes the shape of the trees built by a Pattern, every tree built by the Pattern has the same capture groups nested the same way.
+// Only indexed Patterns have one, see Pattern.Indexed, it is worked out on the first lookup in a tree built by the Pattern, see load, lookups then use it to go straight to the matchValues they need instead of scanning every Nested slice.
+type pathTable struct {
+	once sync.Once
+	// names and parents are those of the capture groups of the Pattern, see parents.
+	names   []string
+	parents []int
+	// counts holds the length of the Nested slice of each capture group, it tells whether a tree still has the shape it was built with.
+	counts []int
+	// children holds, for each capture group, the positions in its Nested slice of the capture groups nested in it, by key.
+	children []map[string][]int
+	// nested holds, for each capture group, the capture groups nested in it in the order of its Nested slice.
+	nested [][]int
+	// keys holds the key paths of the trees, in the order Keys returns them.
+	keys [][]string
+}
+
+// newPathTable returns the pathTable of the capture groups named names, nested as parents tells, see parents, counts holds the number of capture groups nested in each.
+// Nothing is worked out until the pathTable is loaded.
+func newPathTable(names []string, parents, counts []int) *pathTable {
+	return &pathTable{names: names, parents: parents, counts: counts}
+}
+
+// load works out the pathTable the first time it is called, it returns t.
+func (t *pathTable) load() *pathTable {
+	t.once.Do(t.build)
+	return t
+}
+
+// build works out the positions, the nesting and the key paths of the capture groups, see pathTable.
+func (t *pathTable) build() {
+	names := t.names
+	t.children = make([]map[string][]int, len(names))
+	t.nested = make([][]int, len(names))
+	// paths holds the key path of each capture group, seen the joined key paths already in keys, keys cannot contain dots so joined paths are unique.
+	paths := make([][]string, len(names))
+	seen := make(map[string]bool)
+	for j := 1; j < len(names); j++ {
+		parent := t.parents[j]
+		if t.children[parent] == nil {
+			t.children[parent] = make(map[string][]int)
+		}
+		t.children[parent][names[j]] = append(t.children[parent][names[j]], len(t.nested[parent]))
+		t.nested[parent] = append(t.nested[parent], j)
+
+		paths[j] = append(slices.Clip(paths[parent]), names[j])
+		if joined := strings.Join(paths[j], "."); !seen[joined] {
+			seen[joined] = true
+			t.keys = append(t.keys, paths[j])
+		}
+	}
+}
+
+// shaped reports whether the tree rooted at bound still has the shape the pathTable t gives to the capture group capture: every Nested slice down the tree has its length and its keys.
+func shaped[N node[N]](t *pathTable, bound N, capture int) bool {
+	if len(bound.nested()) != t.counts[capture] {
+		return false
+	}
+	for i, inner := range bound.nested() {
+		j := t.nested[capture][i]
+		if inner.key() != t.names[j] || !shaped(t, inner, j) {
+			return false
+		}
+	}
+	return true
+}
+
+// minIndexed is the length from which MatchValue.Index indexes a Nested slice by key, shorter slices are scanned faster than they are indexed.
+const minIndexed = 8
+
+// keyIndex holds the positions of the nodes in the Nested slice of a node, by key, see MatchValue.Index.
+// It is built on the first lookup in the node, see load.
+type keyIndex struct {
+	once sync.Once
+	// count is the length of the Nested slice when the keyIndex was built.
+	count     int
+	positions map[string][]int
+}
+
+// load builds the keyIndex the first time it is called from the count nodes of the Nested slice, key returns the key of the node at position i, it returns index.
+func (index *keyIndex) load(count int, key func(i int) string) *keyIndex {
+	index.once.Do(func() {
+		index.count = count
+		index.positions = make(map[string][]int)
+		for i := range count {
+			index.positions[key(i)] = append(index.positions[key(i)], i)
+		}
+	})
+	return index
+}
+
+// childrenByKey returns the positions in the Nested slice of bound of the nodes whose key is key, as found in the pathTable of the indexed Pattern that built bound or in the keyIndex of bound.
+// It returns false if bound has neither, or if its Nested slice no longer has the length they were made for or the key at one of the positions, the nodes must then be searched for.
+func childrenByKey[N node[N]](bound N, key string) ([]int, bool) {
+	nested := bound.nested()
+	var positions []int
+	if t, capture := bound.table(); t != nil {
+		if len(nested) != t.counts[capture] {
+			return nil, false
+		}
+		positions = t.load().children[capture][key]
+	} else if index := bound.keyIndex(); index != nil {
+		if index.load(len(nested), func(i int) string { return nested[i].key() }).count != len(nested) {
+			return nil, false
+		}
+		positions = index.positions[key]
+	} else {
+		return nil, false
+	}
+	for _, i := range positions {
+		if nested[i].key() != key {
+			return nil, false
+		}
+	}
+	return positions, true
+}
+
+// Index makes the lookups in the tree rooted at mv, such as Get and GetAll, go through an index of the keys of each Nested slice holding many matchValues,
+// instead of scanning the slice. The index of a Nested slice is built on the first lookup in it, it is useful for trees that were not built by an indexed Pattern, see Pattern.Indexed,
+// for example trees read from JSON. Index must be called before the tree is shared with other goroutines.
+//
+// Lookups trust the index as long as each Nested slice keeps its length and the matchValues the index lists keep their key,
+// replacing a matchValue of a Nested slice or changing its Key is not supported, call Index again after doing so.
+func (mv *MatchValue) Index() {
+	mv.byKey = nil
+	if len(mv.Nested) >= minIndexed {
+		mv.byKey = &keyIndex{}
+	}
+	for _, inner := range mv.Nested {
+		inner.Index()
+	}
+}
+
+// keySet records the key paths added by descendKeys, there is a keySet for each key path met, nested by key.
+type keySet struct {
+	// added is set once the key path is added.
+	added  bool
+	nested map[string]*keySet
+}
+
+// child returns the keySet of the key path extended by key, it is created if the key path was not met yet.
+func (s *keySet) child(key string) *keySet {
+	if s.nested == nil {
+		s.nested = make(map[string]*keySet)
+	}
+	next, ok := s.nested[key]
+	if !ok {
+		next = &keySet{}
+		s.nested[key] = next
+	}
+	return next
+}
+
+// keysOf returns the key paths of the nodes nested in bound, see Matches.Keys.
+// The key paths of a tree built by a Pattern are copied from its pathTable as long as the tree has kept its shape, see shaped, otherwise they are collected by descendKeys.
+func keysOf[N node[N]](bound N) [][]string {
+	if t, capture := bound.table(); t != nil && capture == 0 && shaped(t.load(), bound, 0) {
+		if len(t.keys) == 0 {
+			return nil
+		}
+		keys := make([][]string, len(t.keys))
+		for i, path := range t.keys {
+			keys[i] = slices.Clone(path)
+		}
+		return keys
+	}
+	var keys [][]string
+	descendKeys(bound, &keys, &keySet{}, false)
+	return keys
+}
@@ -0,0 +1,231 @@
+package subexpnames_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+// unindexed returns a copy of the matches that was not built by a Pattern, lookups in it search every Nested slice.
+func unindexed(t testing.TB, m *subexpnames.Matches) *subexpnames.Matches {
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied subexpnames.Matches
+	if err := json.Unmarshal(data, &copied); err != nil {
+		t.Fatal(err)
+	}
+	return &copied
+}
+
+func TestIndexedLookups(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<date>(?P<n>\d+)-(?P<n>\d+)(?:-(?P<n>\d+))?)(?: (?P<t>(?P<n>\d+):(?P<n>\d+)))?|(?P<word>[a-z]+)`).Indexed()
+	subject := "2016-01-02 10:30 word 1234-56 x"
+	indexed, ok := p.Match(subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	plain := unindexed(t, indexed)
+	paths := [][]string{{}, {"date"}, {"date", "n"}, {"t", "n"}, {"t"}, {"word"}, {"n"}, {"date", "x"}, {"x"}}
+	for group := range indexed.Len() {
+		if k, expected := indexed.Keys(group), plain.Keys(group); !slicesEqual(k, expected) {
+			t.Fatalf("group %d: expected keys %v, got %v", group, expected, k)
+		}
+		if k, expected := indexed.Unmatched(group), plain.Unmatched(group); !slicesEqual(k, expected) {
+			t.Fatalf("group %d: expected unmatched keys %v, got %v", group, expected, k)
+		}
+		for _, path := range paths {
+			values, ok := indexed.GetAll(group, path...)
+			expected, expectedOk := plain.GetAll(group, path...)
+			if ok != expectedOk || !slices.Equal(values, expected) {
+				t.Fatalf("group %d %v: expected %q %v, got %q %v", group, path, expected, expectedOk, values, ok)
+			}
+			values, ok = indexed.GetAllMatched(group, path...)
+			expected, expectedOk = plain.GetAllMatched(group, path...)
+			if ok != expectedOk || !slices.Equal(values, expected) {
+				t.Fatalf("group %d %v: expected matched %q %v, got %q %v", group, path, expected, expectedOk, values, ok)
+			}
+		}
+	}
+	for _, path := range paths {
+		var values, expected []string
+		for _, v := range indexed.Values(path...) {
+			values = append(values, v)
+		}
+		for _, v := range plain.Values(path...) {
+			expected = append(expected, v)
+		}
+		if !slices.Equal(values, expected) {
+			t.Fatalf("%v: expected values %q, got %q", path, expected, values)
+		}
+	}
+
+	// the keys returned can be modified without changing the next ones.
+	k := indexed.Keys(0)
+	k[0][0] = "changed"
+	if k := indexed.Keys(0); k[0][0] != "date" {
+		t.Fatalf("expected date, got %q", k[0][0])
+	}
+
+	b, ok := p.MatchBytes([]byte(subject))
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	for group := range indexed.Len() {
+		if k, expected := b.Keys(group), plain.Keys(group); !slicesEqual(k, expected) {
+			t.Fatalf("group %d: expected keys %v, got %v", group, expected, k)
+		}
+		for _, path := range paths {
+			values, ok := b.GetAllStrings(group, path...)
+			expected, expectedOk := plain.GetAll(group, path...)
+			if ok != expectedOk || !slices.Equal(values, expected) {
+				t.Fatalf("group %d %v: expected %q %v, got %q %v", group, path, expected, expectedOk, values, ok)
+			}
+		}
+	}
+}
+
+func TestIndexedLookupsModified(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<a>(?P<b>x)(?P<b>y))(?P<c>z)`).Indexed()
+	m, ok := p.Match("xyz")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	root := (*m)[0]
+
+	// Nested slices that no longer have the length they were built with are searched.
+	a := root.Nested[0]
+	a.Nested = append(a.Nested, &subexpnames.MatchValue{Key: "b", Value: "w", Matched: true})
+	expectValues(t, m, 0, []string{"a", "b"}, []string{"x", "y", "w"})
+
+	// Keys notices changes anywhere in the tree.
+	b := a.Nested[1]
+	b.Nested = append(b.Nested, &subexpnames.MatchValue{Key: "d", Value: "v", Matched: true})
+	if k, expected := m.Keys(0), unindexed(t, m).Keys(0); !slicesEqual(k, expected) || !slices.ContainsFunc(k, func(path []string) bool { return slices.Equal(path, []string{"a", "b", "d"}) }) {
+		t.Fatalf("expected keys %v, got %v", expected, k)
+	}
+	a.Nested[0] = &subexpnames.MatchValue{Key: "z", Value: "x", Matched: true}
+	if k, expected := m.Keys(0), unindexed(t, m).Keys(0); !slicesEqual(k, expected) || !slices.ContainsFunc(k, func(path []string) bool { return slices.Equal(path, []string{"a", "z"}) }) {
+		t.Fatalf("expected keys %v, got %v", expected, k)
+	}
+}
+
+func TestLookupsReplaced(t *testing.T) {
+	re := regexp.MustCompile(`(?P<a>x)(?P<b>y)(?P<a>z)`)
+	fromPattern, _ := subexpnames.NewPattern(re).Match("xyz")
+	fromRegexp, _ := subexpnames.Match(re, "xyz")
+	// trees that are not indexed are scanned, a matchValue replaced in a Nested slice is found.
+	for _, m := range []*subexpnames.Matches{fromPattern, fromRegexp} {
+		(*m)[0].Nested[1] = &subexpnames.MatchValue{Key: "a", Value: "NEW", Matched: true}
+		expectValues(t, m, 0, []string{"a"}, []string{"x", "NEW", "z"})
+	}
+}
+
+func TestKeyIndex(t *testing.T) {
+	p := subexpnames.MustCompile(widePatternRepeated(10))
+	indexed, ok := p.Match(strings.Repeat("x", 20))
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	plain := unindexed(t, indexed)
+	(*plain)[0].Index()
+	cloned := &subexpnames.Matches{(*plain)[0].Clone()}
+
+	// the first lookups build the index of the root, they may run concurrently.
+	var wg sync.WaitGroup
+	for _, m := range []*subexpnames.Matches{plain, plain, cloned, cloned} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if values, ok := m.GetAll(0, "g3", "v"); !ok || !slices.Equal(values, []string{"x", "x"}) {
+				t.Errorf("expected [x x], got %q", values)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, m := range []*subexpnames.Matches{plain, cloned} {
+		for i := range 10 {
+			key := fmt.Sprintf("g%d", i)
+			expected, _ := indexed.GetAll(0, key)
+			if values, ok := m.GetAll(0, key); !ok || !slices.Equal(values, expected) {
+				t.Fatalf("%s: expected %q, got %q", key, expected, values)
+			}
+		}
+		if _, ok := m.GetAll(0, "missing"); ok {
+			t.Fatalf("expected no value for missing")
+		}
+
+		// MatchValues appended after the index was built are still found.
+		root := (*m)[0]
+		root.Nested = append(root.Nested, &subexpnames.MatchValue{Key: "g3", Value: "yy", Matched: true})
+		expectValues(t, m, 0, []string{"g3"}, []string{"xx", "yy"})
+
+		// a replaced matchValue is found once the tree is indexed again.
+		root.Nested[0] = &subexpnames.MatchValue{Key: "g3", Value: "zz", Matched: true}
+		root.Index()
+		expectValues(t, m, 0, []string{"g3"}, []string{"zz", "xx", "yy"})
+	}
+}
+
+// slicesEqual reports whether two lists of key paths are equal.
+func slicesEqual(a, b [][]string) bool {
+	return slices.EqualFunc(a, b, slices.Equal)
+}
+
+// widePatternRepeated returns a pattern with n named capture groups side by side, each holding two capture groups named the same.
+func widePatternRepeated(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "(?P<g%d>(?P<v>[a-z])(?P<v>[a-z]))", i)
+	}
+	return sb.String()
+}
+
+func benchmarkLookups(b *testing.B, m *subexpnames.Matches) {
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Get(0, 1, "g79", "v")
+		}
+	})
+	b.Run("GetAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.GetAll(0, "g40")
+		}
+	})
+	b.Run("Keys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Keys(0)
+		}
+	})
+}
+
+func BenchmarkLookups(b *testing.B) {
+	p := subexpnames.MustCompile(widePatternRepeated(80))
+	subject := strings.Repeat("x", 160)
+	m, ok := p.Indexed().Match(subject)
+	if !ok {
+		b.Fatalf("expected a match")
+	}
+	b.Run("Indexed", func(b *testing.B) {
+		benchmarkLookups(b, m)
+	})
+	read := unindexed(b, m)
+	(*read)[0].Index()
+	b.Run("Index", func(b *testing.B) {
+		benchmarkLookups(b, read)
+	})
+	plain, _ := p.Match(subject)
+	b.Run("Unindexed", func(b *testing.B) {
+		benchmarkLookups(b, plain)
+	})
+}
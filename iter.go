@@ -0,0 +1,99 @@
+package subexpnames
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// All returns an iterator over the matches and their indexes, in order.
+func (rm *Matches) All() iter.Seq2[int, *MatchValue] {
+	return func(yield func(int, *MatchValue) bool) {
+		for i, mv := range *rm {
+			if !yield(i, mv) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants returns an iterator over the matchValues nested in mv at any depth, in the order they appear in the tree, mv itself is not included.
+// Every matchValue is yielded, including those that did not take part in the match.
+func (mv *MatchValue) Descendants() iter.Seq[*MatchValue] {
+	return func(yield func(*MatchValue) bool) {
+		mv.descendants(yield)
+	}
+}
+
+// descendants yields the matchValues nested in mv, it returns false once yield does.
+func (mv *MatchValue) descendants(yield func(*MatchValue) bool) bool {
+	for _, inner := range mv.Nested {
+		if !yield(inner) || !inner.descendants(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Values returns an iterator over the values that match the provided keys in every match, together with the index of the match they are found in.
+// It yields the values GetAll returns for each match in turn, but the values are found as the iteration goes and none are collected, so stopping early saves the rest of the work.
+func (rm *Matches) Values(keys ...string) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		for i, mv := range *rm {
+			ok := eachAt(mv, keys, func(node *MatchValue) bool {
+				return yield(i, node.Value)
+			})
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// eachAt calls fn for each matchValue identified by keys in the tree rooted at mv, in the order descend returns them.
+// It returns false as soon as fn does.
+func eachAt(mv *MatchValue, keys []string, fn func(*MatchValue) bool) bool {
+	if len(keys) == 0 {
+		return fn(mv)
+	}
+	if positions, ok := childrenByKey(mv, keys[0]); ok {
+		for _, i := range positions {
+			if !eachAt(mv.Nested[i], keys[1:], fn) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, inner := range mv.Nested {
+		if inner.Key == keys[0] && !eachAt(inner, keys[1:], fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths returns an iterator over the keys of the specified match, it yields the key paths Keys returns in the same order.
+// Each path is a new slice that can be retained. If the match is not found, the iterator yields nothing.
+func (rm *Matches) Paths(group int) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		if group < 0 || group >= len(*rm) {
+			return
+		}
+		seen := make(map[string]bool)
+		Walk((*rm)[group], func(path []string, node *MatchValue) WalkAction {
+			if len(path) == 0 {
+				return Continue
+			}
+			// keys cannot contain dots, so joined paths are unique.
+			key := strings.Join(path, ".")
+			if seen[key] {
+				return Continue
+			}
+			seen[key] = true
+			if !yield(slices.Clone(path)) {
+				return Stop
+			}
+			return Continue
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package subexpnames_test
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestIterators(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))(?: (\w+))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 x 1234-56-78")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	var groups []int
+	for i, mv := range matches.All() {
+		if mv != (*matches)[i] {
+			t.Fatalf("expected match %d", i)
+		}
+		groups = append(groups, i)
+	}
+	if !slices.Equal(groups, []int{0, 1}) {
+		t.Fatalf("expected [0 1], got %v", groups)
+	}
+	for i := range matches.All() {
+		if i > 0 {
+			t.Fatalf("expected the iteration to stop")
+		}
+		break
+	}
+
+	for group := range matches.All() {
+		var values []string
+		for i, v := range matches.Values("overlap", "tens") {
+			if i == group {
+				values = append(values, v)
+			}
+		}
+		expected, _ := matches.GetAll(group, "overlap", "tens")
+		if !slices.Equal(values, expected) {
+			t.Fatalf("expected %v, got %v", expected, values)
+		}
+		paths := slices.Collect(matches.Paths(group))
+		if keys := matches.Keys(group); !slices.EqualFunc(paths, keys, slices.Equal) {
+			t.Fatalf("expected %v, got %v", keys, paths)
+		}
+	}
+	var values []string
+	for _, v := range matches.Values("overlap", "year", "ones") {
+		values = append(values, v)
+	}
+	if !slices.Equal(values, []string{"6", "4"}) {
+		t.Fatalf("expected [6 4], got %v", values)
+	}
+	for i, v := range matches.Values("overlap", "ones") {
+		if i != 0 || v != "6" {
+			t.Fatalf("expected 6 first, got %d %s", i, v)
+		}
+		break
+	}
+	for range matches.Values("missing") {
+		t.Fatalf("expected no values")
+	}
+
+	mv, _ := matches.GetGroup(1)
+	var keys []string
+	for inner := range mv.Descendants() {
+		keys = append(keys, inner.Key)
+	}
+	expected := []string{"overlap", "year", "thousands", "hundreds", "tens", "ones", "month", "tens", "ones", "overlap", "day", "tens", "ones", ""}
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	keys = nil
+	for inner := range mv.Descendants() {
+		keys = append(keys, inner.Key)
+		if inner.Key == "thousands" {
+			break
+		}
+	}
+	if !slices.Equal(keys, expected[:3]) {
+		t.Fatalf("expected %v, got %v", expected[:3], keys)
+	}
+
+	var paths [][]string
+	for path := range matches.Paths(0) {
+		paths = append(paths, path)
+		if len(paths) == 2 {
+			break
+		}
+	}
+	if !slices.EqualFunc(paths, [][]string{{"overlap"}, {"overlap", "year"}}, slices.Equal) {
+		t.Fatalf("expected the first two paths, got %v", paths)
+	}
+	for range matches.Paths(2) {
+		t.Fatalf("expected no paths")
+	}
+}
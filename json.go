@@ -0,0 +1,180 @@
+package subexpnames
+
+import "encoding/json"
+
+// ValueKey is the key holding the value of a capture group that has nested capture groups, in the compact form of a tree, see MatchValue.MarshalCompactJSON.
+// It cannot be the name of a capture group.
+const ValueKey = "$value"
+
+// jsonMatchValue is the tree form of a MatchValue in JSON, see MatchValue.MarshalJSON.
+type jsonMatchValue struct {
+	Key       string            `json:"key"`
+	Value     string            `json:"value"`
+	Matched   bool              `json:"matched"`
+	Start     int               `json:"start"`
+	End       int               `json:"end"`
+	RuneStart int               `json:"runeStart"`
+	RuneEnd   int               `json:"runeEnd"`
+	Nested    []*jsonMatchValue `json:"nested"`
+}
+
+// toJSON returns the tree form of the tree rooted at mv.
+func (mv *MatchValue) toJSON() *jsonMatchValue {
+	j := &jsonMatchValue{
+		Key:       mv.Key,
+		Value:     mv.Value,
+		Matched:   mv.Matched,
+		Start:     mv.start,
+		End:       mv.end,
+		RuneStart: mv.RuneStart(),
+		RuneEnd:   mv.RuneEnd(),
+		Nested:    make([]*jsonMatchValue, len(mv.Nested)),
+	}
+	for i, inner := range mv.Nested {
+		j.Nested[i] = inner.toJSON()
+	}
+	return j
+}
+
+// matchValue returns the tree rooted at j, src is the subject string of the tree, it may be nil.
+func (j *jsonMatchValue) matchValue(src *source) *MatchValue {
+	mv := &MatchValue{
+		Key:     j.Key,
+		Value:   j.Value,
+		Matched: j.Matched,
+		Nested:  make([]*MatchValue, 0, len(j.Nested)),
+		start:   j.Start,
+		end:     j.End,
+		src:     src,
+	}
+	for _, inner := range j.Nested {
+		if inner != nil {
+			mv.Nested = append(mv.Nested, inner.matchValue(src))
+		}
+	}
+	return mv
+}
+
+// within tells whether the offsets of j and of its nested capture groups lie in src and their values are sliced from it.
+// A tree read from JSON can only convert offsets to rune offsets when they do.
+func (j *jsonMatchValue) within(src *source) bool {
+	if j.Start >= 0 || j.End >= 0 {
+		if j.Start < src.offset || j.End < j.Start || j.End > src.offset+len(src.text) || src.value(j.Start, j.End) != j.Value {
+			return false
+		}
+	}
+	for _, inner := range j.Nested {
+		if inner != nil && !inner.within(src) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON implements json.Marshaler, the tree rooted at mv is written in tree form: an object holding the key, the value, the matched flag,
+// the byte and rune offsets of the capture group and its nested capture groups, for example:
+//
+//	{"key":"","value":"2016","matched":true,"start":3,"end":7,"runeStart":3,"runeEnd":7,"nested":[
+//		{"key":"year","value":"2016","matched":true,"start":3,"end":7,"runeStart":3,"runeEnd":7,"nested":[]}
+//	]}
+//
+// Every capture group is written, including those that did not take part in the match, so the tree can be read back with UnmarshalJSON as it was.
+// See MarshalCompactJSON for a form that is easier to consume.
+func (mv *MatchValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mv.toJSON())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it reads a tree written by MarshalJSON, mv becomes its root.
+// The offsets of the capture groups are restored, rune offsets as well as long as the offsets lie within the root and match the values.
+func (mv *MatchValue) UnmarshalJSON(data []byte) error {
+	var j jsonMatchValue
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	// the subject is restored from the root, only the part of it that was matched is known.
+	src := &source{text: j.Value, offset: j.Start, runeOffset: j.RuneStart}
+	if j.Start < 0 || !j.within(src) {
+		src = nil
+	}
+	*mv = *j.matchValue(src)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, each match is written in tree form, see MatchValue.MarshalJSON.
+func (rm *Matches) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]*MatchValue(*rm))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it reads matches written by MarshalJSON.
+func (rm *Matches) UnmarshalJSON(data []byte) error {
+	var matches []*MatchValue
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return err
+	}
+	*rm = matches
+	return nil
+}
+
+// compact returns the compact form of the tree rooted at mv, see MarshalCompactJSON.
+// The root is always returned as a map[string]any, the capture groups nested in it as a string when they have no nested named groups.
+func (mv *MatchValue) compact(root bool) any {
+	children := named(mv)
+	if !root && len(children) == 0 {
+		return mv.Value
+	}
+	count := make(map[string]int)
+	for _, inner := range children {
+		count[inner.Key]++
+	}
+	m := map[string]any{ValueKey: mv.Value}
+	for _, inner := range children {
+		if !inner.Matched {
+			continue
+		}
+		value := inner.compact(false)
+		if count[inner.Key] == 1 {
+			m[inner.Key] = value
+			continue
+		}
+		values, _ := m[inner.Key].([]any)
+		m[inner.Key] = append(values, value)
+	}
+	return m
+}
+
+// named returns the named capture groups nested in mv, the capture groups nested in unnamed groups are returned in their place.
+func named(mv *MatchValue) []*MatchValue {
+	var nodes []*MatchValue
+	for _, inner := range mv.Nested {
+		if inner.Key == RootKey {
+			nodes = append(nodes, named(inner)...)
+			continue
+		}
+		nodes = append(nodes, inner)
+	}
+	return nodes
+}
+
+// MarshalCompactJSON returns the compact form of the tree rooted at mv in JSON: an object mapping the keys of the nested capture groups to their values.
+//   - A capture group without nested named groups is written as its value.
+//   - A capture group with nested named groups is written as an object, its value is held by the key ValueKey.
+//   - A key used by more than one capture group with the same parent is written as an array, even when only one of them took part in the match.
+//   - Capture groups that did not take part in the match are left out, unnamed capture groups are left out and their nested capture groups are written in their place.
+//
+// The root is always written as an object, for example:
+//
+//	{"$value":"2016-01-02","overlap":[{"$value":"2016-01","month":"01","year":"2016"},{"$value":"02","day":"02"}]}
+//
+// The shape of the object only depends on the regular expression, not on the subject, but offsets are lost and the keys are sorted, so it cannot be read back by UnmarshalJSON.
+func (mv *MatchValue) MarshalCompactJSON() ([]byte, error) {
+	return json.Marshal(mv.compact(true))
+}
+
+// MarshalCompactJSON returns the compact form of the matches in JSON, an array holding the compact form of each match, see MatchValue.MarshalCompactJSON.
+func (rm *Matches) MarshalCompactJSON() ([]byte, error) {
+	values := make([]any, len(*rm))
+	for i, mv := range *rm {
+		values[i] = mv.compact(true)
+	}
+	return json.Marshal(values)
+}
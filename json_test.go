@@ -0,0 +1,113 @@
+package subexpnames_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<name>\pL+)(?: (?P<title>[A-Z][a-z]+\.))?=(?P<value>(?P<digit>\d)+)`)
+	subject := "café=12 naïve Dr.=3"
+	matches, ok := subexpnames.Match(regex, subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	data, err := json.Marshal(matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded subexpnames.Matches
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Len() != matches.Len() {
+		t.Fatalf("expected %d matches, got %d", matches.Len(), decoded.Len())
+	}
+	for i, mv := range *matches {
+		expectSameTree(t, mv, decoded[i])
+	}
+
+	// the offsets, including rune offsets, survive the round trip
+	mv, _ := decoded.GetGroup(1)
+	if start, end := mv.Span(); start != 9 || end != 21 {
+		t.Fatalf("expected 9:21, got %d:%d", start, end)
+	}
+	if start, end := mv.RuneSpan(); start != 8 || end != 19 {
+		t.Fatalf("expected 8:19, got %d:%d", start, end)
+	}
+	if offsets, _ := decoded.RuneOffsets(0, "title"); offsets[0] != [2]int{-1, -1} {
+		t.Fatalf("expected -1:-1, got %v", offsets)
+	}
+
+	data, err = json.Marshal(mv.Nested[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"key":"name","value":"naïve","matched":true,"start":9,"end":15,"runeStart":8,"runeEnd":13,"nested":[]}`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestJSONUnmarshalInconsistent(t *testing.T) {
+	// the value of year does not lie in the match, rune offsets fall back to byte offsets
+	data := `{"key":"","value":"2016","matched":true,"start":3,"end":7,"runeStart":1,"runeEnd":5,"nested":[
+		{"key":"year","value":"1999","matched":true,"start":3,"end":7,"runeStart":1,"runeEnd":5,"nested":[]},
+		null
+	]}`
+	var mv subexpnames.MatchValue
+	if err := json.Unmarshal([]byte(data), &mv); err != nil {
+		t.Fatal(err)
+	}
+	if len(mv.Nested) != 1 || mv.Nested[0].Value != "1999" || mv.Nested[0].Start() != 3 || mv.Nested[0].RuneStart() != 3 {
+		t.Fatalf("expected the year 1999 at 3, got %+v", mv.Nested)
+	}
+	if err := json.Unmarshal([]byte(`{"key":1}`), &mv); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?(?: (?P<tag>\w+)(?: (\w+) (?P<note>\w+))?)?`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 a b c;2016-01 x")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	data, err := matches.MarshalCompactJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"$value":"2016-01-02 a b c","note":"c","overlap":[{"$value":"2016-01","month":"01","year":"2016"},{"$value":"02","day":"02"}],"tag":"a"},` +
+		`{"$value":"2016-01 x","overlap":[{"$value":"2016-01","month":"01","year":"2016"}],"tag":"x"}]`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+
+	mv, _ := matches.GetGroup(1)
+	data, err = mv.Nested[0].MarshalCompactJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"$value":"2016-01","month":"01","year":"2016"}`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// the trees are built again on every run, so their rune offsets are worked out again.
+		b.StopTimer()
+		matches, _ := p.Match(subject)
+		b.StartTimer()
+		if _, err := json.Marshal(matches); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package subexpnames
+
+import (
+	"iter"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// lookBehindOps returns the assertions of the parse tree ast that depend on the text before the position they are evaluated at: ^, \A, \b and \B.
+// Matching such a regular expression against the rest of the subject does not give the matches it has in the whole subject, see exactAfter.
+// If ast is nil every assertion is assumed.
+func lookBehindOps(ast *syntax.Regexp) syntax.EmptyOp {
+	if ast == nil {
+		return syntax.EmptyBeginText | syntax.EmptyBeginLine | syntax.EmptyWordBoundary | syntax.EmptyNoWordBoundary
+	}
+	var ops syntax.EmptyOp
+	var walk func(node *syntax.Regexp)
+	walk = func(node *syntax.Regexp) {
+		switch node.Op {
+		case syntax.OpBeginText:
+			ops |= syntax.EmptyBeginText
+		case syntax.OpBeginLine:
+			ops |= syntax.EmptyBeginLine
+		case syntax.OpWordBoundary:
+			ops |= syntax.EmptyWordBoundary
+		case syntax.OpNoWordBoundary:
+			ops |= syntax.EmptyNoWordBoundary
+		}
+		for _, sub := range node.Sub {
+			walk(sub)
+		}
+	}
+	walk(ast)
+	return ops
+}
+
+// exactAfter reports whether searching the Pattern from a position preceded by the rune prev, as if the text started there, finds the matches the whole text has there.
+// The regular expression then reads no assertion differently, prev is -1 at the start of the text.
+// Only matches starting at that position can differ, from the next rune on the assertions read the text before them.
+func (p *Pattern) exactAfter(prev rune) bool {
+	ops := p.behind
+	return prev < 0 || ops&syntax.EmptyBeginText == 0 &&
+		(ops&syntax.EmptyBeginLine == 0 || prev == '\n') &&
+		(ops&(syntax.EmptyWordBoundary|syntax.EmptyNoWordBoundary) == 0 || !syntax.IsWordChar(prev))
+}
+
+// anchored returns a regular expression matching a rune followed by the regular expression of the Pattern, from the start of the text only.
+// Searched from the rune before a position, it finds the match of the Pattern starting at that position with the assertions reading the rune as the text before it, see exactAfter.
+// The submatch indexes of the Pattern follow those of the whole match.
+// It returns nil if the syntax or the matching semantics of the regular expression of the Pattern cannot be told, see compiledWith.
+func (p *Pattern) anchored() *regexp.Regexp {
+	p.anchoredOnce.Do(func() {
+		flags, longest, ok := compiledWith(p.re)
+		if !ok {
+			return
+		}
+		ast, err := syntax.Parse(p.re.String(), flags)
+		if err != nil {
+			return
+		}
+		// the parse tree is written back in the Perl syntax, it keeps the meaning the expression has in the syntax it was compiled with.
+		p.anchoredRe = regexp.MustCompile(`\A(?s:.)(` + ast.String() + `)`)
+		if longest {
+			p.anchoredRe.Longest()
+		}
+	})
+	return p.anchoredRe
+}
+
+// compiledWith returns the syntax re was compiled with and whether it is leftmost-longest, ok is false if they cannot be told.
+// A regexp.Regexp does not record them, so its expression is compiled again the ways this package knows of, by regexp.Compile, regexp.CompilePOSIX,
+// or regexp.Compile followed by Longest, and re is compared with each.
+func compiledWith(re *regexp.Regexp) (flags syntax.Flags, longest, ok bool) {
+	if perl, err := regexp.Compile(re.String()); err == nil {
+		if reflect.DeepEqual(re, perl) {
+			return syntax.Perl, false, true
+		}
+		perl.Longest()
+		if reflect.DeepEqual(re, perl) {
+			return syntax.Perl, true, true
+		}
+	}
+	if posix, err := regexp.CompilePOSIX(re.String()); err == nil && reflect.DeepEqual(re, posix) {
+		return syntax.POSIX, true, true
+	}
+	return 0, false, false
+}
+
+// indexesFrom returns the submatch indexes of the leftmost match of the Pattern in the subject string starting at pos or after, or nil if there is none.
+// The assertions that look behind pos read the subject before it, see exactAfter, the Pattern must then have an anchored regular expression.
+func (p *Pattern) indexesFrom(subject string, pos int) []int {
+	for {
+		prev, size := rune(-1), 0
+		if pos > 0 {
+			prev, size = utf8.DecodeLastRuneInString(subject[:pos])
+		}
+		if p.behind == 0 || p.exactAfter(prev) {
+			return shift(p.re.FindStringSubmatchIndex(subject[pos:]), pos)
+		}
+		if indexes := p.anchored().FindStringSubmatchIndex(subject[pos-size:]); indexes != nil {
+			return shift(indexes[2:], pos-size)
+		}
+		indexes := shift(p.re.FindStringSubmatchIndex(subject[pos:]), pos)
+		if indexes == nil || indexes[0] > pos {
+			return indexes
+		}
+		// the match is only found because the subject seems to start at pos, search again from the next rune.
+		_, width := utf8.DecodeRuneInString(subject[pos:])
+		if width == 0 {
+			return nil
+		}
+		pos += width
+	}
+}
+
+// shift adds offset to the submatch indexes of capture groups that took part in the match, it returns indexes.
+func shift(indexes []int, offset int) []int {
+	for k := range indexes {
+		if indexes[k] >= 0 {
+			indexes[k] += offset
+		}
+	}
+	return indexes
+}
+
+// refill sets the MatchValue of each capture group, as returned by buildNodes, to the submatch indexes of another match in src.
+// The shape of the trees built by a Pattern never changes, so the tree is reused as it is.
+func (p *Pattern) refill(nodes []MatchValue, src *source, indexes []int) {
+	for j := range nodes {
+		mv := &nodes[j]
+		start, end := indexes[2*j], indexes[2*j+1]
+		mv.Value = src.value(start, end)
+		mv.Matched = start >= 0
+		mv.start, mv.end = start, end
+		mv.src = src
+	}
+}
+
+// MatchIter returns an iterator over the matches of the regular expression in the subject string, see Pattern.MatchIter.
+// Like Match, it parses the regular expression on every call.
+func MatchIter(re *regexp.Regexp, subject string) iter.Seq2[int, *MatchValue] {
+	return NewPattern(re).MatchIter(subject)
+}
+
+// MatchIter returns an iterator over the matches of the Pattern in the subject string, it yields the same trees Match returns, together with their index.
+// Each match is searched and its tree built only when the iteration reaches it, so the subject is only scanned as far as the iteration goes.
+//
+// A single tree is allocated and reused for every match: the tree yielded, and the matchValues in it, are only valid until the iteration goes on and must not be modified.
+// Use MatchValue.Clone to keep a tree.
+//
+// Assertions that look behind the position they are evaluated at (^, \A, \b and \B) read the subject before the end of the previous match, so the matches are those Match finds.
+// This needs to know how the regular expression was compiled: by regexp.Compile, regexp.CompilePOSIX, or regexp.Compile followed by Longest,
+// if it was not, the matches of a regular expression with such assertions are all searched when the iteration starts.
+func (p *Pattern) MatchIter(subject string) iter.Seq2[int, *MatchValue] {
+	return func(yield func(int, *MatchValue) bool) {
+		src := &source{text: subject, paths: p.paths}
+		var nodes []MatchValue
+		p.eachIndexes(subject, func(i int, indexes []int) bool {
+			if nodes == nil {
+				nodes = p.buildNodes(src, indexes)
+			} else {
+				p.refill(nodes, src, indexes)
+			}
+			return yield(i, &nodes[0])
+		})
+	}
+}
+
+// eachIndexes calls fn with the submatch indexes of each match of the Pattern in the subject string and its index, as FindAllStringSubmatchIndex returns them.
+// The matches are searched one at a time, each from the end of the previous one, see indexesFrom. It stops as soon as fn returns false.
+// When the assertions of the Pattern look behind and the way its regular expression was compiled cannot be told, see anchored, the matches are all searched first instead.
+func (p *Pattern) eachIndexes(subject string, fn func(i int, indexes []int) bool) {
+	if p.behind != 0 && p.anchored() == nil {
+		for i, indexes := range p.re.FindAllStringSubmatchIndex(subject, -1) {
+			if !fn(i, indexes) {
+				return
+			}
+		}
+		return
+	}
+	// the loop follows regexp's own, so that empty matches are found where FindAll finds them.
+	for i, pos, prevEnd := 0, 0, -1; pos <= len(subject); {
+		indexes := p.indexesFrom(subject, pos)
+		if indexes == nil {
+			return
+		}
+		accept := true
+		if indexes[1] == pos {
+			// an empty match, one right after the previous match is not allowed, the search goes on from the next rune.
+			if indexes[0] == prevEnd {
+				accept = false
+			}
+			if _, size := utf8.DecodeRuneInString(subject[pos:]); size > 0 {
+				pos += size
+			} else {
+				pos = len(subject) + 1
+			}
+		} else {
+			pos = indexes[1]
+		}
+		prevEnd = indexes[1]
+		if accept {
+			if !fn(i, indexes) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Clone returns a copy of the tree rooted at mv, the copy shares no MatchValue with mv.
+// The copy of a tree indexed by Index is indexed as well.
+func (mv *MatchValue) Clone() *MatchValue {
+	clone := *mv
+	if mv.byKey != nil {
+		clone.byKey = &keyIndex{}
+	}
+	clone.Nested = make([]*MatchValue, len(mv.Nested))
+	for i, inner := range mv.Nested {
+		clone.Nested[i] = inner.Clone()
+	}
+	return &clone
+}
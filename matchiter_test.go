@@ -0,0 +1,193 @@
+package subexpnames_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestMatchIter(t *testing.T) {
+	tests := []struct {
+		expr, subject string
+	}{
+		{`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?`, "2016-01-02 and 1234-56, 7890-12-34."},
+		{`(?P<digit>\d)*`, "a12b3 é4"},
+		{`x*`, "xxaxé"},
+		{`(?m)^(?P<line>\w+)$`, "first\nsecond\n\nthird"},
+		{`\b(?P<word>\w)`, "ab cd"},
+		{`^(?P<start>a)`, "aaa"},
+		{`(?m)^(?P<k>\w+)=`, "a=b=c\nd=e\n"},
+		{`\A(?P<a>a)|(?P<b>b)`, "abab"},
+		{`\B(?P<b>b+)`, "abba b bb"},
+		{`(?P<word>\b\w*\b)`, "hello, wide world"},
+		{`(?P<none>z)`, "abc"},
+		{logPattern, logSubject(1 << 10)},
+	}
+	for _, test := range tests {
+		re := regexp.MustCompile(test.expr)
+		expected, ok := subexpnames.Match(re, test.subject)
+		if !ok {
+			expected = &subexpnames.Matches{}
+		}
+		n := 0
+		for i, mv := range subexpnames.MatchIter(re, test.subject) {
+			if i != n || i >= expected.Len() {
+				t.Fatalf("%s: unexpected match %d %q", test.expr, i, mv.Value)
+			}
+			expectSameTree(t, (*expected)[i], mv)
+			n++
+		}
+		if n != expected.Len() {
+			t.Fatalf("%s: expected %d matches, got %d", test.expr, expected.Len(), n)
+		}
+	}
+}
+
+// TestMatchIterCompiled checks the regular expressions that look behind and are not compiled by regexp.Compile:
+// their matches are searched with the syntax and the semantics they were compiled with.
+func TestMatchIterCompiled(t *testing.T) {
+	longest := regexp.MustCompile(`(x)|\B(a|ab)(c|bcd|)(d*)`)
+	longest.Longest()
+	tests := []struct {
+		re      *regexp.Regexp
+		subject string
+	}{
+		// ^ starts a line in the POSIX syntax.
+		{regexp.MustCompilePOSIX(`(^a\n)`), "a\na\n"},
+		{regexp.MustCompilePOSIX(`(^|,)([^,]*)`), "a,bb,,c"},
+		{longest, "xabc"},
+	}
+	for _, test := range tests {
+		expected, ok := subexpnames.Match(test.re, test.subject)
+		if !ok {
+			t.Fatalf("%s: expected a match", test.re)
+		}
+		n := 0
+		for i, mv := range subexpnames.MatchIter(test.re, test.subject) {
+			if i >= expected.Len() {
+				t.Fatalf("%s: unexpected match %d %q", test.re, i, mv.Value)
+			}
+			expectSameTree(t, (*expected)[i], mv)
+			n++
+		}
+		if n != expected.Len() {
+			t.Fatalf("%s: expected %d matches, got %d", test.re, expected.Len(), n)
+		}
+
+		r := &recorder{}
+		subexpnames.MatchEvents(test.re, test.subject, r)
+		var roots []string
+		for i, event := range r.events {
+			// the whole match is entered right after the match starts.
+			if strings.HasPrefix(event, "start ") {
+				roots = append(roots, r.events[i+1])
+			}
+		}
+		if len(roots) != expected.Len() {
+			t.Fatalf("%s: expected %d matches, got events %q", test.re, expected.Len(), roots)
+		}
+		for i, mv := range expected.All() {
+			if want := fmt.Sprintf("< %q %d:%d", mv.Value, mv.Start(), mv.End()); roots[i] != want {
+				t.Fatalf("%s: expected %s, got %s", test.re, want, roots[i])
+			}
+		}
+
+		s := subexpnames.NewScanner(test.re, iotest.OneByteReader(strings.NewReader(test.subject)))
+		n = 0
+		for s.Scan() {
+			if n >= expected.Len() {
+				t.Fatalf("%s: unexpected match %q", test.re, s.Match().Value)
+			}
+			expectSameTree(t, (*expected)[n], s.Match())
+			n++
+		}
+		if n != expected.Len() || s.Err() != nil {
+			t.Fatalf("%s: expected %d matches, got %d, %v", test.re, expected.Len(), n, s.Err())
+		}
+	}
+}
+
+func TestMatchIterReuse(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<key>\w+)=(?P<value>\w+)?`)
+	var first, kept *subexpnames.MatchValue
+	for i, mv := range p.MatchIter("a=1 b= c=3 d=4") {
+		switch i {
+		case 0:
+			first = mv
+			kept = mv.Clone()
+		case 1:
+			if mv != first {
+				t.Fatalf("expected the tree to be reused")
+			}
+			if mv.Nested[1].Matched || mv.Nested[1].Start() != -1 {
+				t.Fatalf("expected value not to take part in the match")
+			}
+		case 2:
+			if v := mv.Nested[1].Value; v != "3" {
+				t.Fatalf("expected 3, got %s", v)
+			}
+		}
+		if i == 2 {
+			break
+		}
+	}
+	if kept.Value != "a=1" || kept.Nested[0].Value != "a" || kept.Nested[1].Value != "1" || kept.Nested[1].Start() != 2 {
+		t.Fatalf("expected the clone to keep the first match, got %v", kept)
+	}
+	if first.Value != "c=3" {
+		t.Fatalf("expected the iteration to stop at c=3, got %s", first.Value)
+	}
+}
+
+func FuzzMatchIter(f *testing.F) {
+	f.Add(`(?P<digit>\d)*`, "a12b3 é4")
+	f.Add(`((a*)(b*))*`, "abba")
+	f.Add(`(?m)^(?P<line>.*)$`, "first\nsecond\n")
+	f.Add(`(?P<word>\w+)(?:\s|$)`, "ab \xffcd")
+	f.Add(`\B(?P<b>b*)|\A(?P<a>a)`, "abba ab")
+	f.Add(`(^a\n)|(x)|\B(a|ab)(c|bcd|)(d*)`, "a\nxabcd\na\n")
+	f.Fuzz(func(t *testing.T, expr, subject string) {
+		// the expression is compiled every way MatchIter knows of.
+		var compiled []*regexp.Regexp
+		if re, err := regexp.Compile(expr); err == nil {
+			longest := regexp.MustCompile(expr)
+			longest.Longest()
+			compiled = append(compiled, re, longest)
+		}
+		if re, err := regexp.CompilePOSIX(expr); err == nil {
+			compiled = append(compiled, re)
+		}
+		for _, re := range compiled {
+			expected, ok := subexpnames.Match(re, subject)
+			if !ok {
+				expected = &subexpnames.Matches{}
+			}
+			n := 0
+			for i, mv := range subexpnames.MatchIter(re, subject) {
+				if i >= expected.Len() {
+					t.Fatalf("%s: unexpected match %d %q", expr, i, mv.Value)
+				}
+				expectSameTree(t, (*expected)[i], mv)
+				n++
+			}
+			if n != expected.Len() {
+				t.Fatalf("%s: expected %d matches, got %d", expr, expected.Len(), n)
+			}
+		}
+	})
+}
+
+func BenchmarkMatchIter(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range p.MatchIter(subject) {
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package subexpnames
+
+import (
+	"sync"
+	"unicode/utf8"
+)
+
+// source is the subject string a tree of matchValues was built from.
+// It is shared by every MatchValue of a call to Match and is used to slice values and to convert byte offsets into rune offsets.
+// When matching an io.Reader, see Scanner, text holds only the part of the input the match was found in.
+type source struct {
+	text string
+	// offset is the byte offset of text in the input, runeOffset is the number of runes in the input before text.
+	offset, runeOffset int
+	// paths is the pathTable of the Pattern that built the matchValues, nil if they were not built by an indexed Pattern.
+	paths *pathTable
+	// checkpoints is built on first use by checkpointsOnce, see runes.
+	checkpointsOnce sync.Once
+	checkpoints     []runeCheckpoint
+}
+
+// runeStride is the number of bytes of text between two checkpoints of a source, see runes.
+const runeStride = 512
+
+// runeCheckpoint records the number of runes in the text of a source before the first rune starting at or after a multiple of runeStride bytes.
+type runeCheckpoint struct {
+	offset, runes int
+}
+
+// value returns the substring of the input between the byte offsets start and end.
+// Capture groups that do not take part in the match have negative indexes, their value is the empty string.
+func (src *source) value(start, end int) string {
+	if start < 0 || end < 0 {
+		return ""
+	}
+	return src.text[start-src.offset : end-src.offset]
+}
+
+// runes returns the number of runes in the input before the byte offset.
+// Negative offsets, used by capture groups that did not take part in the match, are returned unchanged.
+// The text is counted once, the first time a rune offset is asked for, see runeCheckpoint,
+// so each conversion only counts the runes since the last checkpoint and converting the offsets of a whole tree takes time linear in the length of the text.
+func (src *source) runes(offset int) int {
+	if src == nil || offset < 0 {
+		return offset
+	}
+	offset -= src.offset
+	if len(src.text) <= runeStride {
+		return src.runeOffset + utf8.RuneCountInString(src.text[:offset])
+	}
+	src.checkpointsOnce.Do(src.checkpoint)
+	c := src.checkpoints[offset/runeStride]
+	if c.offset > offset {
+		// offset is in the middle of the rune the checkpoint was moved past.
+		c = src.checkpoints[offset/runeStride-1]
+	}
+	return src.runeOffset + c.runes + utf8.RuneCountInString(src.text[c.offset:offset])
+}
+
+// checkpoint records the runeCheckpoints of the text of src, it decodes the text the way regexp does so that every checkpoint is at the start of a rune.
+func (src *source) checkpoint() {
+	src.checkpoints = make([]runeCheckpoint, 0, len(src.text)/runeStride+1)
+	for offset, runes := 0, 0; offset <= len(src.text); runes++ {
+		if offset >= len(src.checkpoints)*runeStride {
+			src.checkpoints = append(src.checkpoints, runeCheckpoint{offset, runes})
+		}
+		if offset == len(src.text) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(src.text[offset:])
+		offset += size
+	}
+}
+
+// Start returns the byte index in the subject string where the match starts.
+// It returns -1 if the capture group did not take part in the match.
+func (mv *MatchValue) Start() int {
+	return mv.start
+}
+
+// End returns the byte index in the subject string where the match ends, the match is subject[Start():End()].
+// It returns -1 if the capture group did not take part in the match.
+func (mv *MatchValue) End() int {
+	return mv.end
+}
+
+// Span returns the byte indexes in the subject string where the match starts and ends.
+// It returns -1, -1 if the capture group did not take part in the match.
+func (mv *MatchValue) Span() (start, end int) {
+	return mv.start, mv.end
+}
+
+// RuneStart is like Start but returns the index as a number of runes (characters) rather than bytes.
+// This is the column of the match for subjects that contain non-ASCII characters.
+// MatchValues that were not created by this package have no subject string, for them the byte index is returned.
+func (mv *MatchValue) RuneStart() int {
+	return mv.src.runes(mv.start)
+}
+
+// RuneEnd is like End but returns the index as a number of runes (characters) rather than bytes.
+// MatchValues that were not created by this package have no subject string, for them the byte index is returned.
+func (mv *MatchValue) RuneEnd() int {
+	return mv.src.runes(mv.end)
+}
+
+// RuneSpan is like Span but returns the indexes as a number of runes (characters) rather than bytes.
+// MatchValues that were not created by this package have no subject string, for them the byte indexes are returned.
+func (mv *MatchValue) RuneSpan() (start, end int) {
+	return mv.RuneStart(), mv.RuneEnd()
+}
+
+// Offsets retrieves the byte offsets of all the values that match the provided keys from the specified match.
+// It mirrors GetAll, the i-th offset pair holds the start and end of the i-th value returned by GetAll.
+// If the match or keys are not found, it returns nil and false.
+func (rm *Matches) Offsets(group int, keys ...string) ([][2]int, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	offsets := offsetsOf(descend((*rm)[group], false, keys...), (*MatchValue).Span)
+	return offsets, offsets != nil
+}
+
+// RuneOffsets is like Offsets but returns the offsets as a number of runes (characters) rather than bytes.
+func (rm *Matches) RuneOffsets(group int, keys ...string) ([][2]int, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	offsets := offsetsOf(descend((*rm)[group], false, keys...), (*MatchValue).RuneSpan)
+	return offsets, offsets != nil
+}
+
+// offsetsOf returns the start and end of each of the nodes given by span, nil if there are no nodes.
+func offsetsOf(nodes []*MatchValue, span func(*MatchValue) (int, int)) [][2]int {
+	if len(nodes) == 0 {
+		return nil
+	}
+	offsets := make([][2]int, len(nodes))
+	for i, mv := range nodes {
+		offsets[i][0], offsets[i][1] = span(mv)
+	}
+	return offsets
+}
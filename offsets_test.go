@@ -0,0 +1,90 @@
+package subexpnames_test
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestOffsets(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<name>\pL+)(?: (?P<title>[A-Z][a-z]+\.))?=(?P<value>\d+)`)
+	subject := "café=12 naïve Dr.=3 x=4"
+	matches, ok := subexpnames.Match(regex, subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	match, _ := matches.GetGroup(1)
+	if start, end := match.Span(); subject[start:end] != match.Value || match.Start() != start || match.End() != end {
+		t.Fatalf("expected %q, got %q", match.Value, subject[start:end])
+	}
+	if start, end := match.Span(); start != 9 || end != 21 {
+		t.Fatalf("expected 9:21, got %d:%d", start, end)
+	}
+	// "café=12 " holds one two-byte rune, so rune offsets are one less than byte offsets
+	if start, end := match.RuneSpan(); start != 8 || end != 19 || match.RuneStart() != start || match.RuneEnd() != end {
+		t.Fatalf("expected 8:19, got %d:%d", start, end)
+	}
+
+	offsets, ok := matches.Offsets(1, "name")
+	if !ok || !slices.Equal(offsets, [][2]int{{9, 15}}) {
+		t.Fatalf("expected [[9 15]], got %v", offsets)
+	}
+	offsets, ok = matches.RuneOffsets(1, "name")
+	if !ok || !slices.Equal(offsets, [][2]int{{8, 13}}) {
+		t.Fatalf("expected [[8 13]], got %v", offsets)
+	}
+
+	// title does not take part in the last match
+	offsets, ok = matches.Offsets(2, "title")
+	if !ok || !slices.Equal(offsets, [][2]int{{-1, -1}}) {
+		t.Fatalf("expected [[-1 -1]], got %v", offsets)
+	}
+	offsets, ok = matches.RuneOffsets(2, "title")
+	if !ok || !slices.Equal(offsets, [][2]int{{-1, -1}}) {
+		t.Fatalf("expected [[-1 -1]], got %v", offsets)
+	}
+
+	if _, ok := matches.Offsets(3); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := matches.Offsets(0, "not-found"); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := matches.RuneOffsets(3); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := matches.RuneOffsets(0, "not-found"); ok {
+		t.Fatalf("expected not found")
+	}
+}
+
+func TestRuneOffsetsWithoutSubject(t *testing.T) {
+	mv := &subexpnames.MatchValue{Key: "key", Value: "value"}
+	if start, end := mv.RuneSpan(); start != 0 || end != 0 {
+		t.Fatalf("expected 0:0, got %d:%d", start, end)
+	}
+}
+
+func TestRuneOffsetsLongSubject(t *testing.T) {
+	// runes of every width and invalid bytes, so that the runes counted in a long subject cross its checkpoints anywhere.
+	subject := strings.Repeat("a é 中 😀 \xff\xe4\xb8 bc ", 300)
+	matches, ok := subexpnames.Match(regexp.MustCompile(`(?P<word>\S+)`), subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	for group := range matches.Len() {
+		match, _ := matches.GetGroup(group)
+		start, end := match.RuneSpan()
+		if want := utf8.RuneCountInString(subject[:match.Start()]); start != want {
+			t.Fatalf("group %d: expected rune start %d, got %d", group, want, start)
+		}
+		if want := utf8.RuneCountInString(subject[:match.End()]); end != want {
+			t.Fatalf("group %d: expected rune end %d, got %d", group, want, end)
+		}
+	}
+}
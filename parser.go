@@ -0,0 +1,113 @@
+package subexpnames
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Parser parses subject strings into values of type T, a struct whose fields are mapped to the capture groups of a Pattern with tags, see Unmarshal.
+// The tags are checked against the Pattern once, when the Parser is created, so that a misspelled tag or a capture group removed from the regular expression is reported then,
+// rather than leaving fields with their zero value when parsing.
+// A Parser is safe for concurrent use by multiple goroutines.
+type Parser[T any] struct {
+	p *Pattern
+}
+
+// NewParser returns a Parser for the struct type T and the regular expression.
+// It returns a *FieldError if a tag of T cannot be parsed, a field has a type that is not supported or a path does not select any capture group of the regular expression.
+func NewParser[T any](re *regexp.Regexp) (*Parser[T], error) {
+	return NewPatternParser[T](NewPattern(re))
+}
+
+// NewPatternParser is like NewParser but takes a Pattern.
+func NewPatternParser[T any](p *Pattern) (*Parser[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, &InvalidUnmarshalError{Type: reflect.PointerTo(t)}
+	}
+	if err := p.check(t, []*MatchValue{p.Skeleton()}, make(map[reflect.Type]bool)); err != nil {
+		return nil, err
+	}
+	return &Parser[T]{p: p}, nil
+}
+
+// MustNewParser is like NewParser but panics if the Parser cannot be created.
+// It simplifies safe initialization of global variables holding parsers.
+func MustNewParser[T any](re *regexp.Regexp) *Parser[T] {
+	ps, err := NewParser[T](re)
+	if err != nil {
+		panic(err)
+	}
+	return ps
+}
+
+// Pattern returns the Pattern the Parser matches subjects with.
+func (ps *Parser[T]) Pattern() *Pattern {
+	return ps.p
+}
+
+// Parse fills a T with the leftmost match of the Pattern in the subject string, see Unmarshal.
+// It returns ErrNoMatch if the subject does not match.
+func (ps *Parser[T]) Parse(subject string) (T, error) {
+	var v T
+	match, ok := ps.p.MatchFirst(subject)
+	if !ok {
+		return v, ErrNoMatch
+	}
+	err := Unmarshal(match, &v)
+	return v, err
+}
+
+// ParseAll fills a T with each match of the Pattern in the subject string, see Unmarshal.
+// It returns ErrNoMatch if the subject does not match.
+func (ps *Parser[T]) ParseAll(subject string) ([]T, error) {
+	matches, ok := ps.p.Match(subject)
+	if !ok {
+		return nil, ErrNoMatch
+	}
+	values := make([]T, len(*matches))
+	for i, match := range *matches {
+		if err := Unmarshal(match, &values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// check returns an error if a field of the struct type t cannot be filled from the nodes, which are part of the skeleton of the Pattern.
+// A path is valid if it selects a capture group from any of the nodes, so a slice of structs can be filled from groups that have different nested groups.
+// visiting holds the struct types being checked, the fields of recursive types are only checked at the first level they appear.
+func (p *Pattern) check(t reflect.Type, nodes []*MatchValue, visiting map[reflect.Type]bool) error {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	fields, err := fieldsOf(t)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		var selected []*MatchValue
+		for _, node := range nodes {
+			selected = append(selected, f.path.Select(node)...)
+		}
+		if len(selected) == 0 {
+			return &FieldError{Struct: t, Field: f.name, Err: fmt.Errorf("path %q does not select any capture group of %q", f.path, p)}
+		}
+		nested := f.typ
+		for nested.Kind() == reflect.Slice || nested.Kind() == reflect.Pointer {
+			if isScalar(nested) {
+				break
+			}
+			nested = nested.Elem()
+		}
+		if !isScalar(nested) && nested.Kind() == reflect.Struct {
+			if err := p.check(nested, selected, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+type logLine struct {
+	Date struct {
+		Year  int `subexp:"year"`
+		Month int `subexp:"month"`
+		Day   int `subexp:"day"`
+	} `subexp:"time.date"`
+	Hour    int    `subexp:"time.clock.hour"`
+	Level   string `subexp:"level"`
+	Message string `subexp:"message"`
+}
+
+func TestParser(t *testing.T) {
+	ps, err := subexpnames.NewParser[logLine](regexp.MustCompile(logPattern))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ps.Pattern().String() != logPattern {
+		t.Fatalf("expected the parser pattern to be %q", logPattern)
+	}
+
+	line, err := ps.Parse("2024-02-03T04:05:06 WARN disk almost full")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line.Date.Year != 2024 || line.Date.Month != 2 || line.Date.Day != 3 || line.Hour != 4 || line.Level != "WARN" || line.Message != "disk almost full" {
+		t.Fatalf("unexpected line %+v", line)
+	}
+
+	if _, err := ps.Parse("not a log line"); !errors.Is(err, subexpnames.ErrNoMatch) {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+
+	subject := logSubject(1 << 10)
+	lines, err := ps.ParseAll(subject)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != strings.Count(subject, "\n") {
+		t.Fatalf("expected %d lines, got %d", strings.Count(subject, "\n"), len(lines))
+	}
+	if lines[2].Level != "ERROR" || lines[2].Date.Day != 3 {
+		t.Fatalf("unexpected line %+v", lines[2])
+	}
+	if _, err := ps.ParseAll("not a log line"); !errors.Is(err, subexpnames.ErrNoMatch) {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestParserConversionError(t *testing.T) {
+	type number struct {
+		Value int `subexp:"value"`
+	}
+	ps := subexpnames.MustNewParser[number](regexp.MustCompile(`(?P<value>\w+)`))
+	var unmarshalErr *subexpnames.UnmarshalError
+	if _, err := ps.Parse("abc"); !errors.As(err, &unmarshalErr) {
+		t.Fatalf("expected an UnmarshalError, got %v", err)
+	}
+	if _, err := ps.ParseAll("1 abc"); !errors.As(err, &unmarshalErr) {
+		t.Fatalf("expected an UnmarshalError, got %v", err)
+	}
+	if values, err := ps.ParseAll("1 2"); err != nil || len(values) != 2 || values[1].Value != 2 {
+		t.Fatalf("expected [1 2], got %v, %v", values, err)
+	}
+}
+
+func TestParserChecksTags(t *testing.T) {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+
+	// groups missing from some of the overlap groups are valid as long as one of them has it
+	type overlap struct {
+		Year *struct {
+			Tens int `subexp:"tens"`
+		} `subexp:"year"`
+		Day   *int   `subexp:"day"`
+		Ones  []int  `subexp:"**.ones"`
+		Value string `subexp:""`
+	}
+	type valid struct {
+		Overlap []overlap `subexp:"overlap"`
+	}
+	if _, err := subexpnames.NewParser[valid](re); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// recursive types are checked down to the first level they appear at
+	if _, err := subexpnames.NewParser[list](regexp.MustCompile(`(?P<next>a(?P<next>b))`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fieldErr *subexpnames.FieldError
+	type misspelled struct {
+		Year int `subexp:"overlap.yaer"`
+	}
+	if _, err := subexpnames.NewParser[misspelled](re); !errors.As(err, &fieldErr) || fieldErr.Field != "Year" {
+		t.Fatalf("expected a FieldError for Year, got %v", err)
+	}
+
+	type nested struct {
+		Overlap []struct {
+			Week int `subexp:"week"`
+		} `subexp:"overlap"`
+	}
+	if _, err := subexpnames.NewParser[nested](re); !errors.As(err, &fieldErr) || fieldErr.Field != "Week" || err.Error() == "" {
+		t.Fatalf("expected a FieldError for Week, got %v", err)
+	}
+
+	type badTag struct {
+		Year int `subexp:"overlap..year"`
+	}
+	if _, err := subexpnames.NewParser[badTag](re); !errors.As(err, &fieldErr) {
+		t.Fatalf("expected a FieldError, got %v", err)
+	}
+
+	var invalid *subexpnames.InvalidUnmarshalError
+	if _, err := subexpnames.NewParser[*valid](re); !errors.As(err, &invalid) {
+		t.Fatalf("expected an InvalidUnmarshalError, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	subexpnames.MustNewParser[misspelled](re)
+}
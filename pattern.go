@@ -0,0 +1,133 @@
+package subexpnames
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sync"
+)
+
+// Pattern is a compiled regular expression together with the hierarchy of its capture groups.
+// The names of the capture groups and the way they are nested are worked out once, when the Pattern is created, and reused by every call to Match.
+// A Pattern is safe for concurrent use by multiple goroutines.
+type Pattern struct {
+	re *regexp.Regexp
+	// names holds the name of each capture group, index 0 is the whole match.
+	names []string
+	// parents holds the index of the capture group enclosing each capture group, see parents.
+	parents []int
+	// children holds the number of capture groups directly nested in each capture group, it sizes the Nested slices of the trees.
+	children []int
+	// paths describes the key paths of the trees built by the Pattern, see pathTable, it is nil unless the Pattern is indexed, see Indexed.
+	paths *pathTable
+	// behind holds the assertions of the regular expression that depend on the text before the position they are evaluated at, see lookBehindOps.
+	behind syntax.EmptyOp
+	// anchoredRe is built on first use by anchoredOnce, see anchored.
+	anchoredOnce sync.Once
+	anchoredRe   *regexp.Regexp
+}
+
+// NewPattern returns a Pattern for an already compiled regular expression.
+func NewPattern(re *regexp.Regexp) *Pattern {
+	// re has already been compiled so the parse should not fail, if it does every capture group is attached to the whole match.
+	ast, _ := parse(re)
+	p := &Pattern{
+		re:      re,
+		names:   re.SubexpNames(),
+		parents: parents(ast, re.NumSubexp()),
+		behind:  lookBehindOps(ast),
+	}
+	p.children = make([]int, len(p.parents))
+	for _, parent := range p.parents[1:] {
+		p.children[parent]++
+	}
+	return p
+}
+
+// Indexed returns a Pattern for the same regular expression whose trees are indexed: the Pattern works out once, on the first lookup, where each key leads in its trees,
+// so lookups in them go straight to the matchValues they need and take time in proportion to the values found rather than to the size of the tree.
+// This pays off for patterns with many capture groups.
+//
+// Lookups trust the index as long as each Nested slice they go through keeps its length and the matchValues the index lists keep their key,
+// replacing a matchValue of a Nested slice or changing its Key is not supported. Keys checks the whole tree and is always exact.
+func (p *Pattern) Indexed() *Pattern {
+	if p.paths != nil {
+		return p
+	}
+	return &Pattern{
+		re:       p.re,
+		names:    p.names,
+		parents:  p.parents,
+		children: p.children,
+		paths:    newPathTable(p.names, p.parents, p.children),
+		behind:   p.behind,
+	}
+}
+
+// Compile parses a regular expression and returns, if successful, a Pattern that can be used to match against text.
+// See regexp.Compile for the syntax of the regular expression.
+func Compile(expr string) (*Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return NewPattern(re), nil
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+// It simplifies safe initialization of global variables holding compiled patterns.
+func MustCompile(expr string) *Pattern {
+	return NewPattern(regexp.MustCompile(expr))
+}
+
+// Regexp returns the regular expression the Pattern was created from.
+func (p *Pattern) Regexp() *regexp.Regexp {
+	return p.re
+}
+
+// String returns the source text used to compile the regular expression.
+func (p *Pattern) String() string {
+	return p.re.String()
+}
+
+// Skeleton returns a tree with a MatchValue for each capture group of the Pattern, nested as the capture groups are in the regular expression.
+// It has the shape of every tree built by the Pattern, none of its capture groups took part in a match so their values are empty and their offsets are -1.
+// It is useful to inspect the hierarchy of the capture groups, for example to check that a path selects a capture group, or to generate code.
+func (p *Pattern) Skeleton() *MatchValue {
+	indexes := make([]int, 2*len(p.names))
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	root := p.build(&source{paths: p.paths}, indexes)
+	// the root of a match always took part in it, the root of the skeleton did not.
+	root.Matched = false
+	return root
+}
+
+// Match checks if the subject string matches the Pattern.
+// If a match is found, it returns a Matches object containing the tree-like structure of matchValues.
+// Otherwise, it returns nil and false.
+func (p *Pattern) Match(subject string) (*Matches, bool) {
+	return p.MatchN(subject, -1)
+}
+
+// MatchN is like Match but stops after n matches, following the semantics of regexp's n parameter:
+// if n >= 0, at most n matches are returned, and if n < 0 all of them are.
+// The subject is only scanned as far as needed to find the n matches, and only their trees are built.
+func (p *Pattern) MatchN(subject string, n int) (*Matches, bool) {
+	indexes := p.re.FindAllStringSubmatchIndex(subject, n)
+	if indexes == nil {
+		return nil, false
+	}
+	return p.tree(subject, indexes), true
+}
+
+// MatchFirst returns the tree of the leftmost match of the Pattern in the subject string.
+// The subject is only scanned up to the first match.
+// If no match is found, it returns nil and false.
+func (p *Pattern) MatchFirst(subject string) (*MatchValue, bool) {
+	indexes := p.re.FindStringSubmatchIndex(subject)
+	if indexes == nil {
+		return nil, false
+	}
+	return p.build(&source{text: subject, paths: p.paths}, indexes), true
+}
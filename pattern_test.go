@@ -0,0 +1,137 @@
+package subexpnames_test
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestCompile(t *testing.T) {
+	if _, err := subexpnames.Compile(`(?P<broken`); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	expr := `(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`
+	p, err := subexpnames.Compile(expr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.String() != expr || p.Regexp().String() != expr {
+		t.Fatalf("expected %q, got %q", expr, p.String())
+	}
+
+	if _, ok := p.Match("not a match"); ok {
+		t.Fatalf("expected not a match")
+	}
+
+	subject := "this is a test subject to see if we can parse 2016-01-02 and 1234-56-78 using the Match() function."
+	// the same pattern must produce the same trees on every call
+	for i := 0; i < 2; i++ {
+		match, ok := p.Match(subject)
+		if !ok {
+			t.Fatalf("expected a match")
+		}
+		expected, _ := subexpnames.Match(regexp.MustCompile(expr), subject)
+		if match.Len() != expected.Len() {
+			t.Fatalf("expected %d groups, got %d", expected.Len(), match.Len())
+		}
+		for group := 0; group < match.Len(); group++ {
+			for _, keys := range expected.Keys(group) {
+				want, _ := expected.GetAll(group, keys...)
+				got, _ := match.GetAll(group, keys...)
+				if slices.Compare(want, got) != 0 {
+					t.Fatalf("%v: expected %q, got %q", keys, want, got)
+				}
+			}
+		}
+		expectValue(t, match, 1, []string{"overlap", "day", "ones"}, "8")
+	}
+}
+
+func TestMustCompile(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<digits>\d+)`)
+	if matches, ok := p.Match("a1b22"); !ok || matches.Len() != 2 {
+		t.Fatalf("expected 2 matches")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	subexpnames.MustCompile(`(`)
+}
+
+func TestNewPattern(t *testing.T) {
+	re := regexp.MustCompile(`(?P<word>\w+)`)
+	if p := subexpnames.NewPattern(re); p.Regexp() != re {
+		t.Fatalf("expected the same regexp")
+	}
+}
+
+func TestMatchN(t *testing.T) {
+	re := regexp.MustCompile(`(?P<digit>\d)`)
+	subject := "0 1 2 3 4 5 6 7 8 9"
+
+	tests := []struct {
+		n, expected int
+	}{
+		{-1, 10},
+		{3, 3},
+		{10, 10},
+		{20, 10},
+	}
+	for _, test := range tests {
+		matches, ok := subexpnames.MatchN(re, subject, test.n)
+		if !ok || matches.Len() != test.expected {
+			t.Fatalf("n=%d: expected %d matches", test.n, test.expected)
+		}
+		expectValue(t, matches, matches.Len()-1, []string{"digit"}, fmt.Sprint(test.expected-1))
+	}
+
+	if _, ok := subexpnames.MatchN(re, subject, 0); ok {
+		t.Fatalf("expected no match for n=0")
+	}
+	if _, ok := subexpnames.MatchN(re, "no digits", -1); ok {
+		t.Fatalf("expected not a match")
+	}
+}
+
+func TestMatchFirst(t *testing.T) {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	subject := "this is a test subject to see if we can parse 2016-01-02 and 1234-56-78 using the Match() function."
+
+	if _, ok := subexpnames.MatchFirst(re, "not a match"); ok {
+		t.Fatalf("expected not a match")
+	}
+
+	first, ok := subexpnames.MatchFirst(re, subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	all, _ := subexpnames.Match(re, subject)
+	want, _ := all.GetGroup(0)
+	expectSameTree(t, want, first)
+}
+
+func TestSkeleton(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<date>(?P<year>\d{4})-(?P<month>\d{2}))(?:T(?P<hour>\d{2}))?(x)`)
+	skeleton := p.Skeleton()
+	expected := [][]string{{"date"}, {"date", "year"}, {"date", "month"}, {"hour"}, {""}}
+	keys := (&subexpnames.Matches{skeleton}).Keys(0)
+	if !slices.EqualFunc(keys, expected, slices.Equal[[]string]) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	if skeleton.Matched || skeleton.Start() != -1 {
+		t.Fatalf("expected the root not to be matched")
+	}
+	if (&subexpnames.Matches{skeleton}).Unmatched(0) == nil {
+		t.Fatalf("expected the capture groups not to be matched")
+	}
+	if year := skeleton.Nested[0].Nested[0]; year.Value != "" || year.Matched || year.Start() != -1 {
+		t.Fatalf("expected an empty year, got %q", year.Value)
+	}
+}
@@ -0,0 +1,235 @@
+package subexpnames
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// stepKind tells how a step of a Path selects the nodes it is applied to.
+type stepKind int
+
+const (
+	// stepKey selects the nested matchValues with a given key.
+	stepKey stepKind = iota
+	// stepAny selects every nested matchValue, it is written as *.
+	stepAny
+	// stepDescendants selects the matchValue itself and all its descendants, it is written as **.
+	stepDescendants
+)
+
+// step is a single segment of a Path.
+type step struct {
+	kind stepKind
+	key  string
+	// index selects the index-th of the nested matchValues selected by the step, -1 selects all of them.
+	index int
+}
+
+// Path is a compiled query that selects matchValues in a tree of matches.
+// It is made of segments separated by dots, each segment is applied to the matchValues selected by the previous one:
+//   - name selects the nested matchValues whose key is name, for example overlap.year.tens.
+//   - * selects every nested matchValue, whatever its key, for example overlap.*.
+//   - ** selects the matchValue itself and all its descendants, at any depth, for example **.ones.
+//
+// A name or * segment can be followed by an index in square brackets, it selects only the index-th (0-based) of the nested matchValues selected by the segment, for example overlap[1].day.
+// The empty path selects the matchValue it is applied to.
+//
+// A Path is safe for concurrent use by multiple goroutines.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// PathError describes a path that cannot be parsed.
+type PathError struct {
+	// Path is the path being parsed.
+	Path string
+	// Offset is the byte offset in Path where the error was found.
+	Offset int
+	// Reason describes the error.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	return fmt.Sprintf("subexpnames: invalid path %q at offset %d: %s", e.Path, e.Offset, e.Reason)
+}
+
+// isKeyByte tells whether c can be part of the name of a capture group.
+func isKeyByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// ParsePath parses a path, see Path for its syntax.
+func ParsePath(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	if expr == "" {
+		return p, nil
+	}
+	for i := 0; ; {
+		st := step{index: -1}
+		segment := i
+		switch {
+		case strings.HasPrefix(expr[i:], "**"):
+			st.kind = stepDescendants
+			i += 2
+		case expr[i] == '*':
+			st.kind = stepAny
+			i++
+		default:
+			for i < len(expr) && isKeyByte(expr[i]) {
+				i++
+			}
+			if i == segment {
+				return nil, &PathError{Path: expr, Offset: i, Reason: "expected a key, * or **"}
+			}
+			st.key = expr[segment:i]
+		}
+		if i < len(expr) && expr[i] == '[' {
+			if st.kind == stepDescendants {
+				return nil, &PathError{Path: expr, Offset: i, Reason: "** cannot be indexed"}
+			}
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, &PathError{Path: expr, Offset: i, Reason: "missing ]"}
+			}
+			index, err := strconv.Atoi(expr[i+1 : i+end])
+			if err != nil || index < 0 || expr[i+1] == '+' {
+				return nil, &PathError{Path: expr, Offset: i + 1, Reason: "index must be a non-negative integer"}
+			}
+			st.index = index
+			i += end + 1
+		}
+		p.steps = append(p.steps, st)
+		if i == len(expr) {
+			return p, nil
+		}
+		if expr[i] != '.' {
+			return nil, &PathError{Path: expr, Offset: i, Reason: "expected ."}
+		}
+		i++
+		if i == len(expr) {
+			return nil, &PathError{Path: expr, Offset: i, Reason: "expected a key, * or **"}
+		}
+	}
+}
+
+// MustParsePath is like ParsePath but panics if the path cannot be parsed.
+// It simplifies safe initialization of global variables holding compiled paths.
+func MustParsePath(expr string) *Path {
+	p, err := ParsePath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source text of the path.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Select returns the matchValues selected by the path in the tree rooted at mv, in the order they appear in the tree.
+// A matchValue selected more than once, which can happen with **, is only returned once.
+func (p *Path) Select(mv *MatchValue) []*MatchValue {
+	nodes := []*MatchValue{mv}
+	descendants := false
+	for _, st := range p.steps {
+		nodes = st.apply(nodes)
+		if len(nodes) == 0 {
+			return nil
+		}
+		descendants = descendants || st.kind == stepDescendants
+	}
+	if descendants {
+		// after ** a node and its descendants can both be selected, steps applied to them no longer return the nodes in tree order.
+		return inTreeOrder(mv, nodes)
+	}
+	return nodes
+}
+
+// inTreeOrder returns the nodes sorted in the order they appear in the tree rooted at mv.
+func inTreeOrder(mv *MatchValue, nodes []*MatchValue) []*MatchValue {
+	selected := make(map[*MatchValue]bool, len(nodes))
+	for _, node := range nodes {
+		selected[node] = true
+	}
+	sorted := make([]*MatchValue, 0, len(nodes))
+	var walk func(mv *MatchValue)
+	walk = func(mv *MatchValue) {
+		if selected[mv] {
+			sorted = append(sorted, mv)
+		}
+		for _, inner := range mv.Nested {
+			walk(inner)
+		}
+	}
+	walk(mv)
+	return sorted
+}
+
+// SelectAll returns the matchValues selected by the path in each group of rm, in order.
+func (p *Path) SelectAll(rm *Matches) []*MatchValue {
+	var nodes []*MatchValue
+	for _, mv := range *rm {
+		nodes = append(nodes, p.Select(mv)...)
+	}
+	return nodes
+}
+
+// apply returns the matchValues selected by the step from each of the nodes.
+func (st step) apply(nodes []*MatchValue) (selected []*MatchValue) {
+	if st.kind == stepDescendants {
+		// the same matchValue is reached more than once when a node is a descendant of another node.
+		seen := make(map[*MatchValue]bool)
+		var walk func(mv *MatchValue)
+		walk = func(mv *MatchValue) {
+			if seen[mv] {
+				return
+			}
+			seen[mv] = true
+			selected = append(selected, mv)
+			for _, inner := range mv.Nested {
+				walk(inner)
+			}
+		}
+		for _, mv := range nodes {
+			walk(mv)
+		}
+		return selected
+	}
+	for _, mv := range nodes {
+		n := 0
+		for _, inner := range mv.Nested {
+			if st.kind == stepKey && inner.Key != st.key {
+				continue
+			}
+			if st.index < 0 || st.index == n {
+				selected = append(selected, inner)
+			}
+			n++
+		}
+	}
+	return selected
+}
+
+// Query returns the matchValues selected by the path in the tree rooted at mv, see Path for the syntax of the path.
+// It returns an error if the path cannot be parsed.
+func (mv *MatchValue) Query(path string) ([]*MatchValue, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Select(mv), nil
+}
+
+// Query returns the matchValues selected by the path in each group, see Path for the syntax of the path.
+// It returns an error if the path cannot be parsed.
+func (rm *Matches) Query(path string) ([]*MatchValue, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.SelectAll(rm), nil
+}
@@ -0,0 +1,136 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+// queryValues returns the values of the matchValues selected by the path in the group.
+func queryValues(t *testing.T, mv *subexpnames.MatchValue, path string) []string {
+	t.Helper()
+	nodes, err := mv.Query(path)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", path, err)
+	}
+	var values []string
+	for _, node := range nodes {
+		values = append(values, node.Value)
+	}
+	return values
+}
+
+func TestQuery(t *testing.T) {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	subject := "this is a test subject to see if we can parse 2016-01-02 and 1234-56-78 using the Match() function."
+	matches, ok := subexpnames.Match(re, subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	mv, _ := matches.GetGroup(0)
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"", []string{"2016-01-02"}},
+		{"overlap", []string{"2016-01", "02"}},
+		{"overlap.year.tens", []string{"1"}},
+		{"overlap[0].year", []string{"2016"}},
+		{"overlap[1].day", []string{"02"}},
+		{"overlap[2]", nil},
+		{"overlap.*", []string{"2016", "01", "02"}},
+		{"overlap.*[1]", []string{"01"}},
+		{"*", []string{"2016-01", "02"}},
+		{"*.*.tens", []string{"1", "0", "0"}},
+		{"**.tens", []string{"1", "0", "0"}},
+		{"**.ones", []string{"6", "1", "2"}},
+		{"**.**.ones", []string{"6", "1", "2"}},
+		{"overlap.**.ones", []string{"6", "1", "2"}},
+		{"**.*[0]", []string{"2016-01", "2016", "2", "0", "02", "0"}},
+		{"**", []string{"2016-01-02", "2016-01", "2016", "2", "0", "1", "6", "01", "0", "1", "02", "02", "0", "2"}},
+		{"not.found", nil},
+		{"overlap.not_found", nil},
+	}
+	for _, test := range tests {
+		if values := queryValues(t, mv, test.path); !slices.Equal(values, test.expected) {
+			t.Fatalf("%s: expected %q, got %q", test.path, test.expected, values)
+		}
+	}
+
+	nodes, err := matches.Query("overlap[1].day.tens")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Value != "0" || nodes[1].Value != "7" {
+		t.Fatalf("expected a value for each group, got %d values", len(nodes))
+	}
+	if nodes[1].Key != "tens" || nodes[1].Start() != 69 {
+		t.Fatalf("expected the tens node at 69, got %q at %d", nodes[1].Key, nodes[1].Start())
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	valid := []string{"", "a", "a.b", "a[0]", "a[10].b", "*", "*[1]", "**", "a.**.b", "_1.x2"}
+	for _, path := range valid {
+		p, err := subexpnames.ParsePath(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if p.String() != path {
+			t.Fatalf("expected %q, got %q", path, p.String())
+		}
+	}
+
+	invalid := []struct {
+		path   string
+		offset int
+	}{
+		{".", 0},
+		{"a.", 2},
+		{"a..b", 2},
+		{"a-b", 1},
+		{"a[", 1},
+		{"a[]", 2},
+		{"a[x]", 2},
+		{"a[-1]", 2},
+		{"a[+1]", 2},
+		{"a[0]b", 4},
+		{"**[0]", 2},
+		{"***", 2},
+	}
+	for _, test := range invalid {
+		_, err := subexpnames.ParsePath(test.path)
+		var pathErr *subexpnames.PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("%s: expected a PathError, got %v", test.path, err)
+		}
+		if pathErr.Path != test.path || pathErr.Offset != test.offset || pathErr.Error() == "" {
+			t.Fatalf("%s: expected an error at offset %d, got %v", test.path, test.offset, err)
+		}
+	}
+
+	mv := &subexpnames.MatchValue{}
+	if _, err := mv.Query("a."); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, err := (&subexpnames.Matches{mv}).Query("a."); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestMustParsePath(t *testing.T) {
+	if p := subexpnames.MustParsePath("a.b"); p.String() != "a.b" {
+		t.Fatalf("expected a.b, got %q", p.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	subexpnames.MustParsePath("a.")
+}
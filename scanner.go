@@ -0,0 +1,289 @@
+package subexpnames
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	// MaxScanSize is the default maximum size of the buffer used by a Scanner.
+	MaxScanSize = 1024 * 1024
+	// startBufSize is the size of the buffer a Scanner allocates first, it grows as needed up to the maximum size.
+	startBufSize = 4096
+	// maxConsecutiveEmptyReads is the number of reads returning no data and no error after which a Scanner gives up.
+	maxConsecutiveEmptyReads = 100
+)
+
+var (
+	// ErrTooLong is returned by Scanner.Err when the input the regular expression reads to decide a match does not fit in the buffer of the Scanner.
+	ErrTooLong = errors.New("subexpnames.Scanner: input read to decide a match too long")
+	// ErrBadReadCount is returned by Scanner.Err when the io.Reader returns an impossible count.
+	ErrBadReadCount = errors.New("subexpnames.Scanner: read returned impossible count")
+)
+
+// Scanner reads the matches of a Pattern from an io.Reader, one tree at a time, without loading the whole input into memory.
+// Successive calls to Scan step through the matches, Match returns the tree of the current match.
+//
+// The regular expression reads the input through the buffer of the Scanner as far as it needs to decide a match, so matches that cross the boundaries of the reads are found.
+// The buffer grows up to a maximum size, see Buffer, once it is full the older half of the input searched so far is discarded.
+// This keeps the memory used bounded, but a match is only found if the input the regular expression reads from its start to decide it fits in half the buffer:
+// that depends on how far the regular expression reads ahead and not on the length of the match, (a.*b|a) reads to the end of the input looking for a b before it settles for an a.
+// Otherwise Err returns ErrTooLong.
+//
+// Offsets, see MatchValue.Start and MatchValue.RuneStart, are relative to the start of the input.
+// Each match is searched from the end of the previous one, assertions that look behind it, such as ^ and \b, read the rune before it so the matches are those Match finds,
+// as long as the regular expression was compiled by regexp.Compile, regexp.CompilePOSIX, or regexp.Compile followed by Longest, see Pattern.MatchIter.
+type Scanner struct {
+	p   *Pattern
+	r   io.Reader
+	buf []byte
+	// maxSize is the maximum size of buf.
+	maxSize int
+	// buf[start:end] is the input that has been read and not yet consumed by a match.
+	start, end int
+	// pos is the index in buf of the next rune the regular expression will read, start <= pos <= end.
+	pos int
+	// offset is the offset in the input of buf[start], runes is the number of runes in the input before buf[start].
+	offset, runes int
+	// prevEnd is the offset in the input where the last match ended, -1 before the first match.
+	prevEnd int
+	// prev is the rune before buf[start] and prevSize its size, prev is -1 at the start of the input.
+	prev     rune
+	prevSize int
+	// lead is set when the regular expression reads prev before the buffered input, see scanReader.
+	lead  bool
+	match *MatchValue
+	err   error
+	eof   bool
+	// done is set once no more matches can be found.
+	done    bool
+	scanned bool
+}
+
+// NewScanner returns a new Scanner reading the matches of the regular expression from r.
+// Like Match, it parses the regular expression on every call, Pattern.Scanner does not.
+func NewScanner(re *regexp.Regexp, r io.Reader) *Scanner {
+	return NewPattern(re).Scanner(r)
+}
+
+// Scanner returns a new Scanner reading the matches of the Pattern from r.
+func (p *Pattern) Scanner(r io.Reader) *Scanner {
+	return &Scanner{
+		p:       p,
+		r:       r,
+		maxSize: MaxScanSize,
+		prevEnd: -1,
+		prev:    -1,
+	}
+}
+
+// Buffer sets the initial buffer to use when reading and the maximum size of buffer that may be allocated during scanning.
+// The maximum size of the buffer is the larger of max and cap(buf).
+// If max <= cap(buf), Scan will use this buffer only and do no allocation.
+//
+// By default, Scan uses an internal buffer and sets the maximum size to MaxScanSize.
+//
+// Buffer panics if it is called after scanning has started.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	if s.scanned {
+		panic("subexpnames.Scanner: Buffer called after Scan")
+	}
+	s.buf = buf[0:cap(buf)]
+	s.maxSize = max
+}
+
+// Match returns the tree of the most recent match found by a call to Scan.
+// The tree is not modified by subsequent calls to Scan.
+func (s *Scanner) Match() *MatchValue {
+	return s.match
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Scan advances the Scanner to the next match, which will then be available through the Match method.
+// It returns false when there are no more matches, either by reaching the end of the input or an error.
+// After Scan returns false, the Err method will return any error that occurred during scanning, except that if it was io.EOF, Err will return nil.
+// The input read before an error is searched as if the input ended there.
+func (s *Scanner) Scan() bool {
+	s.scanned = true
+	s.match = nil
+	for !s.done {
+		var indexes []int
+		if s.p.behind != 0 && !s.p.exactAfter(s.prev) {
+			// the regular expression would read the input as if it started at buf[start], a match starting there is searched with prev before it instead, see Pattern.exactAfter.
+			if anchored := s.p.anchored(); anchored != nil {
+				if indexes = s.search(anchored, true); indexes != nil {
+					indexes = indexes[2:]
+				}
+			}
+			if indexes == nil && s.err != ErrTooLong {
+				start := s.offset
+				indexes = s.search(s.p.re, false)
+				if indexes != nil && indexes[0] == start && start == s.offset {
+					// the match is only found because the input seems to start at buf[start], search again from the next rune.
+					if s.start == s.end {
+						s.done = true
+						break
+					}
+					_, size := utf8.DecodeRune(s.buf[s.start:s.end])
+					s.consume(size)
+					continue
+				}
+			}
+		} else {
+			indexes = s.search(s.p.re, false)
+		}
+		if indexes == nil || s.err == ErrTooLong {
+			s.done = true
+			break
+		}
+		if indexes[0] < s.offset {
+			// the start of the match has been discarded to make room in the buffer.
+			s.err = ErrTooLong
+			s.done = true
+			break
+		}
+		s.consume(indexes[0] - s.offset)
+		if indexes[0] == indexes[1] && indexes[0] == s.prevEnd {
+			// an empty match right after the previous match, regexp does not allow it, search again from the next rune.
+			if s.start == s.end {
+				s.done = true
+				break
+			}
+			_, size := utf8.DecodeRune(s.buf[s.start:s.end])
+			s.consume(size)
+			continue
+		}
+		src := &source{
+			text:       string(s.buf[s.start : s.start+indexes[1]-indexes[0]]),
+			offset:     indexes[0],
+			runeOffset: s.runes,
+			paths:      s.p.paths,
+		}
+		s.consume(indexes[1] - indexes[0])
+		s.prevEnd = indexes[1]
+		s.match = s.p.build(src, indexes)
+		return true
+	}
+	return false
+}
+
+// search returns the submatch indexes of the leftmost match of re in the buffered input and the input after it, as offsets in the input, or nil if there is none.
+// With lead set re reads prev first, its offsets are then those of prev and the match following it.
+func (s *Scanner) search(re *regexp.Regexp, lead bool) []int {
+	offset := s.offset
+	if lead {
+		offset -= s.prevSize
+	}
+	s.pos = s.start
+	s.lead = lead
+	indexes := re.FindReaderSubmatchIndex((*scanReader)(s))
+	if indexes == nil {
+		return nil
+	}
+	for i := range indexes {
+		if indexes[i] >= 0 {
+			indexes[i] += offset
+		}
+	}
+	return indexes
+}
+
+// consume discards the first n bytes of the buffered input.
+func (s *Scanner) consume(n int) {
+	if n > 0 {
+		s.prev, s.prevSize = utf8.DecodeLastRune(s.buf[s.start : s.start+n])
+	}
+	s.runes += utf8.RuneCount(s.buf[s.start : s.start+n])
+	s.start += n
+	s.offset += n
+}
+
+// scanReader is the io.RuneReader the regular expression reads the buffered input of a Scanner through.
+// It reads more input into the buffer as the regular expression needs it, when lead is set the rune before the buffered input comes first.
+type scanReader Scanner
+
+// ReadRune returns the rune at the current position of the Scanner and advances it.
+// At the end of the input, or after an error, it returns io.EOF.
+func (r *scanReader) ReadRune() (rune, int, error) {
+	s := (*Scanner)(r)
+	if s.lead {
+		s.lead = false
+		return s.prev, s.prevSize, nil
+	}
+	for !utf8.FullRune(s.buf[s.pos:s.end]) && !s.eof && s.err == nil {
+		s.fill()
+	}
+	if s.pos == s.end {
+		return 0, 0, io.EOF
+	}
+	c, size := utf8.DecodeRune(s.buf[s.pos:s.end])
+	s.pos += size
+	return c, size, nil
+}
+
+// fill reads more input into the buffer, making room for it first.
+// When the buffer is full and cannot grow, the older half of the input already read by the regular expression is discarded instead,
+// if the match being searched starts there it is too long, see Scan.
+func (s *Scanner) fill() {
+	if s.start > 0 && (s.end == len(s.buf) || s.start > len(s.buf)/2) {
+		copy(s.buf, s.buf[s.start:s.end])
+		s.end -= s.start
+		s.pos -= s.start
+		s.start = 0
+	}
+	if s.end == len(s.buf) {
+		if len(s.buf) >= s.maxSize {
+			half := (s.pos - s.start) / 2
+			for half < s.pos-s.start && !utf8.RuneStart(s.buf[s.start+half]) {
+				half++
+			}
+			if half == 0 {
+				s.err = ErrTooLong
+				return
+			}
+			s.consume(half)
+			return
+		}
+		newSize := len(s.buf) * 2
+		if newSize == 0 {
+			newSize = startBufSize
+		}
+		newSize = min(newSize, s.maxSize)
+		newBuf := make([]byte, newSize)
+		copy(newBuf, s.buf[s.start:s.end])
+		s.end -= s.start
+		s.pos -= s.start
+		s.start = 0
+		s.buf = newBuf
+	}
+	for loop := 0; ; {
+		n, err := s.r.Read(s.buf[s.end:len(s.buf)])
+		if n < 0 || len(s.buf)-s.end < n {
+			s.err = ErrBadReadCount
+			return
+		}
+		s.end += n
+		if err == io.EOF {
+			s.eof = true
+			return
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+		loop++
+		if loop > maxConsecutiveEmptyReads {
+			s.err = io.ErrNoProgress
+			return
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+// expectSameMatches scans subject with the reader returned by wrap and checks that the trees are the same as the ones returned by Match.
+func expectSameMatches(t *testing.T, expr, subject string, wrap func(io.Reader) io.Reader) {
+	t.Helper()
+	re := regexp.MustCompile(expr)
+	expected, ok := subexpnames.Match(re, subject)
+	if !ok {
+		expected = &subexpnames.Matches{}
+	}
+
+	s := subexpnames.NewScanner(re, wrap(strings.NewReader(subject)))
+	group := 0
+	for s.Scan() {
+		if group >= expected.Len() {
+			t.Fatalf("%s: unexpected match %q", expr, s.Match().Value)
+		}
+		want, _ := expected.GetGroup(group)
+		expectSameTree(t, want, s.Match())
+		group++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("%s: unexpected error: %v", expr, err)
+	}
+	if group != expected.Len() {
+		t.Fatalf("%s: expected %d matches, got %d", expr, expected.Len(), group)
+	}
+}
+
+// expectSameTree checks that the trees have the same keys, values and offsets.
+func expectSameTree(t *testing.T, want, got *subexpnames.MatchValue) {
+	t.Helper()
+	if want.Key != got.Key || want.Value != got.Value || want.Matched != got.Matched {
+		t.Fatalf("expected %q=%q, got %q=%q", want.Key, want.Value, got.Key, got.Value)
+	}
+	if ws, we := want.Span(); ws != got.Start() || we != got.End() {
+		t.Fatalf("%q: expected span %d:%d, got %d:%d", want.Key, ws, we, got.Start(), got.End())
+	}
+	if ws, we := want.RuneSpan(); ws != got.RuneStart() || we != got.RuneEnd() {
+		t.Fatalf("%q: expected rune span %d:%d, got %d:%d", want.Key, ws, we, got.RuneStart(), got.RuneEnd())
+	}
+	if len(want.Nested) != len(got.Nested) {
+		t.Fatalf("%q: expected %d nested values, got %d", want.Key, len(want.Nested), len(got.Nested))
+	}
+	for i := range want.Nested {
+		expectSameTree(t, want.Nested[i], got.Nested[i])
+	}
+}
+
+func TestScanner(t *testing.T) {
+	date := `(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`
+	tests := []struct {
+		expr    string
+		subject string
+	}{
+		{date, "this is a test subject to see if we can parse 2016-01-02 and 1234-56-78 using the Match() function."},
+		{date, "no dates here"},
+		{`(?P<word>\pL+)`, "café naïve façade, déjà vu"},
+		{`(?P<digits>\d*)`, "a1b22c333"},
+		{`(?P<x>x*)`, "xxaxbx"},
+		{`(?P<line>[^\n]+\n(?P<continued>[ \t][^\n]*\n)*)`, "first\n  more\n  and more\nsecond\nthird\n\tlast"},
+		{`(?P<year>\d{4})?(?P<month>\d{2})(?P<day>\d{2})`, "0304 20210304"},
+		{`(?P<all>.*)`, ""},
+		{`^(?P<a>a)`, "aaa"},
+		{`\A(?P<a>a)|(?P<b>b)`, "abab"},
+		{`(?m)^(?P<k>\w+)=`, "a=b=c\nd=e\n"},
+		{`(?m)^(?P<line>[^\n]*)$`, "first\nsecond\n\nlast"},
+		{`\b(?P<x>ab)`, "abab ab"},
+		{`\B(?P<b>b+)`, "abba b bb"},
+		{`(?P<word>\b\w*\b)`, "hello, wide world"},
+	}
+	wraps := map[string]func(io.Reader) io.Reader{
+		"reader":     func(r io.Reader) io.Reader { return r },
+		"one byte":   iotest.OneByteReader,
+		"half":       iotest.HalfReader,
+		"data error": iotest.DataErrReader,
+	}
+	for name, wrap := range wraps {
+		for _, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				expectSameMatches(t, test.expr, test.subject, wrap)
+			})
+		}
+	}
+}
+
+func TestScannerLongInput(t *testing.T) {
+	subject := logSubject(1 << 14)
+	p := subexpnames.MustCompile(logPattern)
+	s := p.Scanner(iotest.HalfReader(strings.NewReader(subject)))
+	// a small buffer forces the input to be discarded as it is consumed
+	s.Buffer(make([]byte, 100), 256)
+	expected, _ := p.Match(subject)
+	group := 0
+	for s.Scan() {
+		want, _ := expected.GetGroup(group)
+		expectSameTree(t, want, s.Match())
+		group++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if group != expected.Len() {
+		t.Fatalf("expected %d matches, got %d", expected.Len(), group)
+	}
+}
+
+func TestScannerDiscardsInputWithoutMatches(t *testing.T) {
+	subject := strings.Repeat("é", 1000) + "1234" + strings.Repeat("-", 1000) + "5678"
+	s := subexpnames.NewScanner(regexp.MustCompile(`(?P<number>\d+)`), strings.NewReader(subject))
+	s.Buffer(nil, 64)
+	var values []string
+	var offsets [][2]int
+	for s.Scan() {
+		values = append(values, s.Match().Value)
+		start, end := s.Match().RuneSpan()
+		offsets = append(offsets, [2]int{start, end})
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(values, []string{"1234", "5678"}) {
+		t.Fatalf("expected [1234 5678], got %q", values)
+	}
+	if !slices.Equal(offsets, [][2]int{{1000, 1004}, {2004, 2008}}) {
+		t.Fatalf("expected [[1000 1004] [2004 2008]], got %v", offsets)
+	}
+}
+
+func TestScannerTooLong(t *testing.T) {
+	s := subexpnames.NewScanner(regexp.MustCompile(`(?P<number>\d+)`), strings.NewReader("a 12 "+strings.Repeat("3", 100)))
+	s.Buffer(make([]byte, 16), 16)
+	if !s.Scan() || s.Match().Value != "12" {
+		t.Fatalf("expected 12")
+	}
+	if s.Scan() {
+		t.Fatalf("expected no match, got %q", s.Match().Value)
+	}
+	if !errors.Is(s.Err(), subexpnames.ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", s.Err())
+	}
+
+	// the match is short but the regular expression reads the whole input looking for a longer one.
+	s = subexpnames.NewScanner(regexp.MustCompile(`(a.*b|a)`), strings.NewReader("a"+strings.Repeat("x", 200)))
+	s.Buffer(nil, 64)
+	if s.Scan() || !errors.Is(s.Err(), subexpnames.ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", s.Err())
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	failure := errors.New("failure")
+	s := subexpnames.NewScanner(regexp.MustCompile(`(?P<number>\d+)`), io.MultiReader(strings.NewReader("1 2 3"), iotest.ErrReader(failure)))
+	var values []string
+	for s.Scan() {
+		values = append(values, s.Match().Value)
+	}
+	if !slices.Equal(values, []string{"1", "2", "3"}) {
+		t.Fatalf("expected [1 2 3], got %q", values)
+	}
+	if !errors.Is(s.Err(), failure) {
+		t.Fatalf("expected failure, got %v", s.Err())
+	}
+	if s.Scan() || s.Match() != nil {
+		t.Fatalf("expected no more matches")
+	}
+
+	s = subexpnames.NewScanner(regexp.MustCompile(`\d`), emptyReader{})
+	if s.Scan() {
+		t.Fatalf("expected no match")
+	}
+	if !errors.Is(s.Err(), io.ErrNoProgress) {
+		t.Fatalf("expected io.ErrNoProgress, got %v", s.Err())
+	}
+
+	s = subexpnames.NewScanner(regexp.MustCompile(`\d`), badReader{})
+	if s.Scan() {
+		t.Fatalf("expected no match")
+	}
+	if !errors.Is(s.Err(), subexpnames.ErrBadReadCount) {
+		t.Fatalf("expected ErrBadReadCount, got %v", s.Err())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	s.Buffer(nil, 16)
+}
+
+// emptyReader never returns any data nor an error.
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) { return 0, nil }
+
+// badReader returns an impossible count.
+type badReader struct{}
+
+func (badReader) Read([]byte) (int, error) { return -1, nil }
+
+func BenchmarkScanner(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := p.Scanner(strings.NewReader(subject))
+		for s.Scan() {
+		}
+	}
+}
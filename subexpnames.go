@@ -1,113 +1,198 @@
 // Package subexpnames provides functions for matching regular expressions against strings and extracting match values in a hierarchical manner.
 //
 // subexpnames.Match first creates a tree-like structure of matches by matching a regular expression against a subject string.
+// subexpnames.Compile returns a Pattern that works out the hierarchy of the capture groups once and reuses it on every match.
 //
-// The way the package splits the regular expression into a tree-like structure is by using the regular expression's FindAllStringSubmatchIndex and FindAllStringSubmatch methods.
-// It then finds all matches containing the start and end indexes of the match and the corresponding key and value.
+// The hierarchy of the tree is taken from the regular expression's syntax: each capture group is nested under the capture group that encloses it in the pattern.
+// The tree is then filled with the indexes returned by the regular expression's FindAllStringSubmatchIndex method, values are sliced from the subject so it is only scanned once.
+// Capture groups are numbered in the order they appear in the pattern, so each tree is built in a single pass over them, in time linear in the number of capture groups.
 //
-// Calls to Matches' functions recursively descend into the nested matchValues to find the appropriate match, for this reason using this package on large regular expressions can be slow.
+// Calls to Matches' functions descend into the nested matchValues to find the appropriate match, scanning the Nested slices for the keys asked for.
+// Trees with many capture groups can be indexed instead, see Pattern.Indexed and MatchValue.Index, lookups in them then go straight to the matchValues they need.
 package subexpnames
 
 import (
 	"regexp"
-	"slices"
+	"regexp/syntax"
 )
 
 // MatchValue represents a single match found in the subject string that corresponds to the regular expression.
 // It contains the following information about the match:
 //   - Key: A string that identifies the match, often corresponding to a named capture group in the regular expression.
+//     The root of each match represents the whole match and always has an empty key.
 //   - Value: The substring from the subject string that was matched.
-//   - start: The starting index of the match in the subject string.
-//   - end: The ending index of the match in the subject string.
+//   - Matched: Whether the capture group took part in the match.
+//     Optional capture groups that do not take part in the match are still part of the tree, with an empty Value and Matched set to false,
+//     so that "group absent" can be told apart from "group present but empty".
+//   - start: The starting index of the match in the subject string, see Start.
+//   - end: The ending index of the match in the subject string, see End.
 //   - Nested: A slice of pointers to MatchValue structs representing any nested matches.
 //     Nested matches occur when the regular expression contains capture groups within other capture groups.
 //     This allows for representing the hierarchical structure of matches in a tree-like form.
 type MatchValue struct {
-	Key    string
-	Value  string
-	Nested []*MatchValue
+	Key     string
+	Value   string
+	Matched bool
+	// capture is the index of the capture group of the MatchValue in the Pattern that built it, see source.paths.
+	capture int32
+	Nested  []*MatchValue
 	// start and end represent the indexes of the match in the subject string, they are not exported.
 	start, end int
+	// src is the subject string the match was found in, it is shared by every MatchValue of a call to Match.
+	src *source
+	// byKey indexes Nested by key, nil if it is not indexed, see Index.
+	byKey *keyIndex
 }
 
+// RootKey is the Key of the MatchValue representing the whole match, at the root of each group of Matches.
+// It is the name regexp gives to the whole match, see regexp.Regexp.SubexpNames.
+const RootKey = ""
+
 // Matches represents a collection of MatchValue pointers.
 // It is used to store multiple matches found in a subject string that match a regular expression.
 type Matches []*MatchValue
 
-// find searches for the first MatchValue within the specified start and end indexes in the given bound.
-// It recursively descends into the nested matchValues to find the appropriate match.
-// Returns the found MatchValue and a boolean indicating whether the match is contained within the bounds.
-// If no match is found within the bounds, it returns nil and false.
-func find(bound *MatchValue, start, end int) (mv *MatchValue, contained bool) {
-	for _, inner := range bound.Nested {
-		if start >= inner.start && end <= inner.end {
-			if v, contained := find(inner, start, end); contained {
-				return v, contained
-			}
-			return inner, true
+// parents returns, for each of the n capture groups of the parse tree ast and the whole match, the index of the capture group that encloses it in the pattern.
+// Index 0 is the whole match and has no parent, it is reported as -1. Capture groups that are not enclosed by any other capture group have parent 0.
+// The hierarchy is taken from the regexp/syntax parse tree, so it reflects how the pattern is written and not how the matched indexes line up.
+// If ast is nil every capture group is attached to the whole match.
+func parents(ast *syntax.Regexp, n int) []int {
+	parents := make([]int, n+1)
+	parents[0] = -1
+	if ast == nil {
+		return parents
+	}
+	var walk func(node *syntax.Regexp, parent int)
+	walk = func(node *syntax.Regexp, parent int) {
+		if node.Op == syntax.OpCapture {
+			parents[node.Cap] = parent
+			parent = node.Cap
 		}
+		for _, sub := range node.Sub {
+			walk(sub, parent)
+		}
+	}
+	walk(ast, 0)
+	return parents
+}
+
+// parse returns the regexp/syntax parse tree of the regular expression.
+// The flags re was compiled with are not known, so the Perl syntax of regexp.Compile is tried first and the POSIX syntax of regexp.CompilePOSIX, which accepts expressions Perl rejects such as a**, next.
+func parse(re *regexp.Regexp) (*syntax.Regexp, error) {
+	ast, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return syntax.Parse(re.String(), syntax.POSIX)
 	}
-	return nil, false
+	return ast, nil
 }
 
-// tree takes a regular expression and a subject string, and returns a hierarchical structure of matches.
+// tree takes a subject string and the submatch indexes of the Pattern found in it, and returns a hierarchical structure of matches.
 // The function constructs a tree-like structure where each node represents a match found in the subject string.
-// The tree is built using the regular expression's submatches and their corresponding start and end indexes.
+// Values are sliced from the subject, so the subject is not scanned again.
 // This function is useful for organizing matches in a way that reflects their nested nature in the regular expression.
-func tree(re *regexp.Regexp, subject string) *Matches {
-	matches := make([]*MatchValue, 0)
-	indexes := re.FindAllStringSubmatchIndex(subject, -1)
-	names := re.SubexpNames()
-	match := re.FindAllStringSubmatch(subject, -1)
+func (p *Pattern) tree(subject string, indexes [][]int) *Matches {
+	matches := make([]*MatchValue, 0, len(indexes))
+	src := &source{text: subject, paths: p.paths}
 	for i := 0; i < len(indexes); i++ {
-		matches = append(matches, &MatchValue{
-			Key:    names[i],
-			Value:  match[i][0],
-			start:  indexes[i][0],
-			end:    indexes[i][1],
-			Nested: make([]*MatchValue, 0),
-		})
-
-		bound := matches[i]
-		for j := 2; j < len(indexes[i]); j += 2 {
-			matchStart, matchEnd, key, value := indexes[i][j], indexes[i][j+1], names[j/2], match[i][j/2]
-			inner, contained := find(bound, matchStart, matchEnd)
-			if contained {
-				inner.Nested = append(inner.Nested, &MatchValue{
-					Key:    key,
-					Value:  value,
-					start:  matchStart,
-					end:    matchEnd,
-					Nested: make([]*MatchValue, 0),
-				})
-				continue
-			}
-			bound.Nested = append(bound.Nested, &MatchValue{
-				Key:    key,
-				Value:  value,
-				start:  matchStart,
-				end:    matchEnd,
-				Nested: make([]*MatchValue, 0),
-			})
-		}
+		matches = append(matches, p.build(src, indexes[i]))
 	}
 	return (*Matches)(&matches)
 }
 
-// descend is a helper function that recursively descends into the nested matchValues to retrieve the values based on the provided keys.
-func descend(bound *MatchValue, keys ...string) (values []string) {
+// build returns the tree of a single match of the Pattern, given its submatch indexes in src.
+// The shape of the tree is given by the capture groups of the regular expression, see parents, and it is filled using the submatches and their corresponding start and end indexes.
+func (p *Pattern) build(src *source, indexes []int) *MatchValue {
+	return &p.buildNodes(src, indexes)[0]
+}
+
+// buildNodes is like build but returns the MatchValue of each capture group, index 0 is the root of the tree.
+// The tree is built in a single pass over the capture groups: the MatchValues are allocated together, and so are their Nested slices, each sized to the number of capture groups nested in it, see Pattern.children.
+// Building a tree takes two allocations and time linear in the number of capture groups, however deep or wide they are nested.
+func (p *Pattern) buildNodes(src *source, indexes []int) []MatchValue {
+	names := p.names
+	// nodes holds the MatchValue of each capture group of the match, parents always come before their children.
+	nodes := make([]MatchValue, len(names))
+	// nested backs the Nested slices of every MatchValue, every capture group but the root is nested in exactly one other.
+	nested := make([]*MatchValue, len(names)-1)
+	for j := range names {
+		start, end := indexes[2*j], indexes[2*j+1]
+		nodes[j] = MatchValue{
+			Key:     names[j],
+			Value:   src.value(start, end),
+			Matched: j == 0 || start >= 0,
+			capture: int32(j),
+			start:   start,
+			end:     end,
+			src:     src,
+			// the capacity is exact, appending to Nested later never writes over the Nested of another MatchValue.
+			Nested: nested[:0:p.children[j]],
+		}
+		nested = nested[p.children[j]:]
+		if j > 0 {
+			bound := &nodes[p.parents[j]]
+			bound.Nested = append(bound.Nested, &nodes[j])
+		}
+	}
+	return nodes
+}
+
+// node is implemented by the nodes of the trees built by this package, *MatchValue and *BytesMatchValue.
+// It lets the helpers walking the trees be shared by both.
+type node[N any] interface {
+	key() string
+	matched() bool
+	nested() []N
+	// table returns the pathTable of the Pattern that built the node and the index of its capture group, the pathTable is nil if the node was not built by an indexed Pattern.
+	table() (*pathTable, int)
+	// keyIndex returns the keyIndex of the nested nodes, nil if they are not indexed.
+	keyIndex() *keyIndex
+}
+
+func (mv *MatchValue) key() string           { return mv.Key }
+func (mv *MatchValue) matched() bool         { return mv.Matched }
+func (mv *MatchValue) nested() []*MatchValue { return mv.Nested }
+func (mv *MatchValue) keyIndex() *keyIndex   { return mv.byKey }
+
+func (mv *MatchValue) table() (*pathTable, int) {
+	if mv.src == nil {
+		return nil, 0
+	}
+	return mv.src.paths, int(mv.capture)
+}
+
+// descend is a helper function that recursively descends into the nested matchValues to retrieve the matchValues based on the provided keys.
+// When matchedOnly is true the capture groups that did not take part in the match are skipped.
+// Only the matchValues whose key is the next of keys are visited when the tree is indexed, see childrenByKey.
+func descend[N node[N]](bound N, matchedOnly bool, keys ...string) (nodes []N) {
+	if matchedOnly && !bound.matched() {
+		return nil
+	}
 	if len(keys) == 0 {
-		return []string{bound.Value}
+		return []N{bound}
 	}
 	key := keys[0]
-	for _, mv := range bound.Nested {
-		if mv.Key == key {
-			if len(keys) == 1 {
-				values = append(values, mv.Value)
-			} else {
-				values = append(values, descend(mv, keys[1:]...)...)
-			}
+	if positions, ok := childrenByKey(bound, key); ok {
+		for _, i := range positions {
+			nodes = append(nodes, descend(bound.nested()[i], matchedOnly, keys[1:]...)...)
 		}
+		return nodes
+	}
+	for _, mv := range bound.nested() {
+		if mv.key() == key {
+			nodes = append(nodes, descend(mv, matchedOnly, keys[1:]...)...)
+		}
+	}
+	return nodes
+}
+
+// valuesOf returns the Value of each of the nodes.
+func valuesOf(nodes []*MatchValue) []string {
+	if nodes == nil {
+		return nil
+	}
+	values := make([]string, len(nodes))
+	for i, mv := range nodes {
+		values[i] = mv.Value
 	}
 	return values
 }
@@ -115,27 +200,54 @@ func descend(bound *MatchValue, keys ...string) (values []string) {
 // Match checks if the subject string matches the provided regular expression.
 // If a match is found, it returns a regMatch object containing the tree-like structure of matchValues.
 // Otherwise, it returns nil and false.
+// Match works out the hierarchy of the capture groups on every call, it parses the regular expression again, which on a short subject costs more than matching it.
+// Use Compile or NewPattern when matching the same regular expression repeatedly, the Pattern does it once.
+// MatchErr returns ErrNoMatch instead of false.
 func Match(regexp *regexp.Regexp, subject string) (*Matches, bool) {
-	if !regexp.MatchString(subject) {
-		return nil, false
-	}
-	return tree(regexp, subject), true
+	return NewPattern(regexp).Match(subject)
+}
+
+// MatchN is like Match but stops after n matches, following the semantics of regexp's n parameter:
+// if n >= 0, at most n matches are returned, and if n < 0 all of them are.
+// Like Match, it parses the regular expression on every call, see Pattern.MatchN.
+func MatchN(regexp *regexp.Regexp, subject string, n int) (*Matches, bool) {
+	return NewPattern(regexp).MatchN(subject, n)
+}
+
+// MatchFirst returns the tree of the leftmost match of the regular expression in the subject string, the subject is only scanned up to it.
+// If no match is found, it returns nil and false.
+// Like Match, it parses the regular expression on every call, see Pattern.MatchFirst.
+func MatchFirst(regexp *regexp.Regexp, subject string) (*MatchValue, bool) {
+	return NewPattern(regexp).MatchFirst(subject)
 }
 
 // GetAll retrieves all the values that match the provided keys from the specified match.
 // If the match or keys are not found, it returns nil and false.
 // Otherwise, it returns a slice of strings containing the matching values and true.
+// Capture groups that did not take part in the match are returned as empty strings, see GetAllMatched.
+// GetAllErr returns an error telling a missing match apart from missing keys instead of false.
 func (rm *Matches) GetAll(group int, keys ...string) ([]string, bool) {
 	if group < 0 || group >= len(*rm) {
 		return nil, false
 	}
-	var values []string = descend((*rm)[group], keys...)
+	var values []string = valuesOf(descend((*rm)[group], false, keys...))
+	return values, len(values) > 0
+}
+
+// GetAllMatched is like GetAll but skips the capture groups that did not take part in the match.
+// If none of the capture groups identified by keys took part in the match, it returns nil and false.
+func (rm *Matches) GetAllMatched(group int, keys ...string) ([]string, bool) {
+	if group < 0 || group >= len(*rm) {
+		return nil, false
+	}
+	var values []string = valuesOf(descend((*rm)[group], true, keys...))
 	return values, len(values) > 0
 }
 
 // Get retrieves the value at the specified index from the specified match.
 // If the match, value, or keys are not found, it returns an empty string and false.
 // The function allows for accessing specific values within a group of matches based on their keys.
+// GetErr returns an error telling why the value is not found instead of false.
 func (rm *Matches) Get(group int, value int, keys ...string) (string, bool) {
 	values, ok := rm.GetAll(group, keys...)
 	if !ok {
@@ -149,6 +261,20 @@ func (rm *Matches) Get(group int, value int, keys ...string) (string, bool) {
 	return values[value], true
 }
 
+// GetMatched is like Get but skips the capture groups that did not take part in the match, value indexes only the values that did.
+func (rm *Matches) GetMatched(group int, value int, keys ...string) (string, bool) {
+	values, ok := rm.GetAllMatched(group, keys...)
+	if !ok {
+		return "", false
+	}
+
+	if value < 0 || value >= len(values) {
+		return "", false
+	}
+
+	return values[value], true
+}
+
 // GetFirstValueOfGroup retrieves the first value of the group that matches the provided keys.
 // If the keys sequence is not found, it returns an empty string and false.
 // This function is a convenience method for quickly accessing the first value in a group of matches.
@@ -159,6 +285,7 @@ func (rm *Matches) GetFirstValueOfGroup(group int, keys ...string) (string, bool
 // GetGroup retrieves the match at the specified index from the Matches object.
 // If the index is out of bounds, it returns nil and false.
 // This function provides access to individual match groups within the collection of matches.
+// GetGroupErr returns a *GroupOutOfRangeError instead of false.
 func (rm *Matches) GetGroup(group int) (*MatchValue, bool) {
 	if group < 0 || group >= len(*rm) {
 		return nil, false
@@ -172,34 +299,43 @@ func (rm *Matches) Len() int {
 }
 
 // descendKeys is a helper function that recursively descends into the nested matchValues to retrieve the keys.
-// It accumulates the keys in the 'values' slice, ensuring that each key pair is added only once.
+// It accumulates the keys in the 'values' slice, ensuring that each key pair is added only once: 'seen' records the key pairs met under 'parents'.
+// When unmatchedOnly is true only the keys of capture groups that did not take part in the match are added.
 // The 'parents' parameter is used to keep track of the hierarchy of keys during the recursion.
-func descendKeys(bound *MatchValue, values *[][]string, parents ...string) {
-	for _, mv := range bound.Nested {
+func descendKeys[N node[N]](bound N, values *[][]string, seen *keySet, unmatchedOnly bool, parents ...string) {
+	for _, mv := range bound.nested() {
 		pk := append([]string{}, parents...)
-		pk = append(pk, mv.Key)
-		alreadyExists := false
-		for _, v := range *values {
-			if slices.Compare(v, pk) == 0 {
-				alreadyExists = true
-				break
-			}
-		}
-		if !alreadyExists {
+		pk = append(pk, mv.key())
+		next := seen.child(mv.key())
+		if !next.added && !(unmatchedOnly && mv.matched()) {
+			next.added = true
 			*values = append(*values, pk)
 		}
-		descendKeys(mv, values, pk...)
+		descendKeys(mv, values, next, unmatchedOnly, pk...)
 	}
 }
 
 // Keys retrieves all the keys from the specified group.
 // It returns a slice of slices of strings containing the keys and the keys of their nested matches.
 // If a key pair is repeated, it will only be added once.
+// The keys of a match found by an indexed Pattern are the same for every match, they are worked out once by the Pattern and copied as long as the tree has kept its shape, see Pattern.Indexed.
+// KeysErr returns a *GroupOutOfRangeError instead of nil for an out of range group.
 func (rm *Matches) Keys(group int) [][]string {
+	if group < 0 || group >= len(*rm) {
+		return nil
+	}
+	return keysOf((*rm)[group])
+}
+
+// Unmatched retrieves the keys of the capture groups of the specified group that did not take part in the match.
+// It returns a slice of slices of strings in the same form as Keys, if a key pair is repeated, it will only be added once.
+// Capture groups nested under a group that did not take part in the match are reported as well.
+// UnmatchedErr returns a *GroupOutOfRangeError instead of nil for an out of range group.
+func (rm *Matches) Unmatched(group int) [][]string {
 	if group < 0 || group >= len(*rm) {
 		return nil
 	}
 	var keys [][]string
-	descendKeys((*rm)[group], &keys)
+	descendKeys((*rm)[group], &keys, &keySet{}, true)
 	return keys
 }
@@ -1,8 +1,10 @@
 package subexpnames_test
 
 import (
+	"fmt"
 	"regexp"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/thetechpanda/subexpnames"
@@ -329,3 +331,360 @@ func TestNonCapturingGroups(t *testing.T) {
 	}
 
 }
+
+func TestHierarchyFollowsPattern(t *testing.T) {
+	// an empty group at the end of its sibling must not be nested under it
+	matches, ok := subexpnames.Match(regexp.MustCompile(`(?P<outer>a)(?P<empty>b*)c`), "ac")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	expectValues(t, matches, 0, []string{"outer"}, []string{"a"})
+	expectValues(t, matches, 0, []string{"empty"}, []string{""})
+	if _, ok := matches.Get(0, 0, "outer", "empty"); ok {
+		t.Fatalf("expected empty not to be nested under outer")
+	}
+
+	// siblings spanning the same indexes must stay siblings
+	matches, ok = subexpnames.Match(regexp.MustCompile(`(?P<outer>(?P<first>a*)(?P<second>b*))c`), "c")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	if keys := matches.Keys(0); len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %v", keys)
+	}
+	expectValues(t, matches, 0, []string{"outer", "first"}, []string{""})
+	expectValues(t, matches, 0, []string{"outer", "second"}, []string{""})
+
+	// an optional group that does not take part in the match stays under the group that encloses it
+	matches, ok = subexpnames.Match(regexp.MustCompile(`(?P<outer>a(?P<opt>b)?)c`), "ac")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	match := (*matches)[0]
+	if len(match.Nested) != 1 || match.Nested[0].Key != "outer" {
+		t.Fatalf("expected a single outer group, got %d nested values", len(match.Nested))
+	}
+	if outer := match.Nested[0]; len(outer.Nested) != 1 || outer.Nested[0].Key != "opt" {
+		t.Fatalf("expected opt to be nested under outer")
+	}
+}
+
+func TestHierarchyPOSIX(t *testing.T) {
+	// a** is rejected by the Perl syntax but accepted by the POSIX one
+	matches, ok := subexpnames.Match(regexp.MustCompilePOSIX(`(a(b)**)`), "abb")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	k := matches.Keys(0)
+	if expected := [][]string{{""}, {"", ""}}; !slices.EqualFunc(k, expected, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expected, k)
+	}
+	expectValues(t, matches, 0, []string{"", ""}, []string{"b"})
+}
+
+// logPattern matches the timestamp, level and message of a line produced by logSubject.
+const logPattern = `(?P<time>(?P<date>(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2}))T(?P<clock>(?P<hour>\d{2}):(?P<minute>\d{2}):(?P<second>\d{2})))\s+(?P<level>[A-Z]+)\s+(?P<message>[^\n]*)`
+
+// logSubject returns a multi-line log of roughly size bytes where every line is a match of logPattern.
+func logSubject(size int) string {
+	var sb strings.Builder
+	levels := []string{"INFO", "WARN", "ERROR", "DEBUG"}
+	for i := 0; sb.Len() < size; i++ {
+		fmt.Fprintf(&sb, "2024-%02d-%02dT%02d:%02d:%02d %s request %d served in %dms\n", i%12+1, i%28+1, i%24, i%60, (i*7)%60, levels[i%len(levels)], i, i%1000)
+	}
+	return sb.String()
+}
+
+func TestLogSubject(t *testing.T) {
+	subject := logSubject(1 << 12)
+	matches, ok := subexpnames.MustCompile(logPattern).Match(subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	if lines := strings.Count(subject, "\n"); matches.Len() != lines {
+		t.Fatalf("expected %d matches, got %d", lines, matches.Len())
+	}
+	expectValue(t, matches, 1, []string{"time", "date", "month"}, "02")
+	expectValue(t, matches, 2, []string{"level"}, "ERROR")
+}
+
+// BenchmarkThreeScans scans the subject three times, as Match used to do before building the tree.
+// It is the baseline for BenchmarkMatch and BenchmarkPatternMatch, which scan the subject once and build the tree.
+func BenchmarkThreeScans(b *testing.B) {
+	re := regexp.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if re.MatchString(subject) {
+			re.FindAllStringSubmatchIndex(subject, -1)
+			re.FindAllStringSubmatch(subject, -1)
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	re := regexp.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		subexpnames.Match(re, subject)
+	}
+}
+
+func BenchmarkPatternMatch(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.SetBytes(int64(len(subject)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Match(subject)
+	}
+}
+
+func TestMoreMatchesThanGroups(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<digit>\d)`)
+	subject := "0 1 2 3 4 5 6 7 8 9"
+	matches, ok := subexpnames.Match(regex, subject)
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	if matches.Len() != 10 {
+		t.Fatalf("expected 10 matches, got %d", matches.Len())
+	}
+	for i := 0; i < matches.Len(); i++ {
+		match, _ := matches.GetGroup(i)
+		if match.Key != subexpnames.RootKey {
+			t.Fatalf("group %d: expected the root key, got %q", i, match.Key)
+		}
+		expectValue(t, matches, i, []string{"digit"}, fmt.Sprint(i))
+	}
+}
+
+func TestRootKey(t *testing.T) {
+	// more capture groups than matches must not leak group names into the root key
+	regex := regexp.MustCompile(`(?P<a>x)(?P<b>y)(?P<c>z)`)
+	matches, ok := subexpnames.Match(regex, "xyz xyz")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	for i := 0; i < matches.Len(); i++ {
+		if match, _ := matches.GetGroup(i); match.Key != subexpnames.RootKey {
+			t.Fatalf("group %d: expected the root key, got %q", i, match.Key)
+		}
+	}
+}
+
+// FuzzMatch checks that Match does not panic on any valid regular expression and subject.
+func FuzzMatch(f *testing.F) {
+	f.Add(`(?P<digit>\d)`, "0 1 2 3 4 5 6 7 8 9")
+	f.Add(`(?P<year>\d{4})?(?P<month>\d{2})(?P<day>\d{2})`, "20210304")
+	f.Add(`(?P<outer>(?P<inner1>\d+)|(?P<inner2>[a-z]+))`, "123abc")
+	f.Add(`((a*)(b*))*`, "abba")
+	f.Add(`(?m)^(?P<line>.*)$`, "first\nsecond\n")
+	f.Add(`(x)?`, "")
+	f.Fuzz(func(t *testing.T, expr, subject string) {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return
+		}
+		matches, ok := subexpnames.Match(re, subject)
+		if !ok {
+			return
+		}
+		for i := 0; i < matches.Len(); i++ {
+			for _, keys := range matches.Keys(i) {
+				matches.GetAll(i, keys...)
+			}
+		}
+	})
+}
+
+func TestUnmatchedGroups(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<date>(?P<year>\d{4}-)?(?P<month>\d{2})-(?P<day>\d{2}))(?P<suffix>Z?)`)
+	matches, ok := subexpnames.Match(regex, "03-04")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	match := (*matches)[0]
+	if !match.Matched {
+		t.Fatalf("expected the whole match to be matched")
+	}
+	date := match.Nested[0]
+	if year := date.Nested[0]; year.Key != "year" || year.Matched || year.Value != "" {
+		t.Fatalf("expected year not to be matched, got %q", year.Value)
+	}
+	if suffix := match.Nested[1]; suffix.Key != "suffix" || !suffix.Matched || suffix.Value != "" {
+		t.Fatalf("expected suffix to be matched and empty, got %q", suffix.Value)
+	}
+
+	// GetAll reports the absent year as an empty value, GetAllMatched skips it
+	expectValues(t, matches, 0, []string{"date", "year"}, []string{""})
+	if v, ok := matches.GetAllMatched(0, "date", "year"); ok {
+		t.Fatalf("expected year to be skipped, got %q", v)
+	}
+	if _, ok := matches.GetMatched(0, 0, "date", "year"); ok {
+		t.Fatalf("expected year to be skipped")
+	}
+	if v, ok := matches.GetMatched(0, 0, "suffix"); !ok || v != "" {
+		t.Fatalf("expected an empty suffix, got %q", v)
+	}
+	if v, ok := matches.GetMatched(0, 0, "date", "day"); !ok || v != "04" {
+		t.Fatalf("expected 04, got %q", v)
+	}
+	if _, ok := matches.GetMatched(0, 1, "date", "day"); ok {
+		t.Fatalf("expected not found")
+	}
+	if _, ok := matches.GetAllMatched(1); ok {
+		t.Fatalf("expected not found")
+	}
+
+	unmatched := matches.Unmatched(0)
+	if len(unmatched) != 1 || slices.Compare(unmatched[0], []string{"date", "year"}) != 0 {
+		t.Fatalf("expected [[date year]], got %v", unmatched)
+	}
+	if matches.Unmatched(1) != nil {
+		t.Fatalf("expected no keys")
+	}
+}
+
+func TestUnmatchedAlternation(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<value>(?P<number>(?P<digits>\d+))|(?P<word>\w+))`)
+	matches, ok := subexpnames.Match(regex, "abc")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	expected := [][]string{{"value", "number"}, {"value", "number", "digits"}}
+	if unmatched := matches.Unmatched(0); len(unmatched) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, unmatched)
+	} else {
+		for i := range expected {
+			if slices.Compare(unmatched[i], expected[i]) != 0 {
+				t.Fatalf("expected %v, got %v", expected, unmatched)
+			}
+		}
+	}
+	if v, ok := matches.GetAllMatched(0, "value", "word"); !ok || v[0] != "abc" {
+		t.Fatalf("expected abc, got %q", v)
+	}
+}
+
+func BenchmarkMatchFirst(b *testing.B) {
+	p := subexpnames.MustCompile(logPattern)
+	subject := logSubject(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.MatchFirst(subject)
+	}
+}
+
+// widePattern returns a pattern with n named capture groups side by side, matching n letters.
+func widePattern(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "(?P<g%d>[a-z])", i)
+	}
+	return sb.String()
+}
+
+// deepPattern returns a pattern with n named capture groups each nested in the previous one, matching n letters.
+func deepPattern(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "(?P<d%d>[a-z]", i)
+	}
+	sb.WriteString(strings.Repeat(")", n))
+	return sb.String()
+}
+
+// balancedPattern returns a pattern whose named capture groups form a tree with the given breadth and depth, each leaf matching a letter.
+func balancedPattern(breadth, depth int) string {
+	if depth == 0 {
+		return "[a-z]"
+	}
+	var sb strings.Builder
+	for i := 0; i < breadth; i++ {
+		fmt.Fprintf(&sb, "(?P<b%d>%s)", i, balancedPattern(breadth, depth-1))
+	}
+	return sb.String()
+}
+
+func TestLargePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		letters int
+		groups  int
+		depth   int
+	}{
+		{"wide", widePattern(400), 400, 400, 1},
+		{"deep", deepPattern(200), 200, 200, 200},
+		{"balanced", balancedPattern(4, 4), 256, 340, 4},
+	}
+	for _, test := range tests {
+		p := subexpnames.MustCompile(test.expr)
+		subject := strings.Repeat("x", test.letters)
+		mv, ok := p.MatchFirst(subject)
+		if !ok {
+			t.Fatalf("%s: expected a match", test.name)
+		}
+		groups, depth := 0, 0
+		subexpnames.Walk(mv, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+			if len(path) > 0 {
+				groups++
+			}
+			depth = max(depth, len(path))
+			return subexpnames.Continue
+		})
+		if groups != test.groups || depth != test.depth {
+			t.Fatalf("%s: expected %d groups %d deep, got %d groups %d deep", test.name, test.groups, test.depth, groups, depth)
+		}
+	}
+}
+
+func benchmarkLargePattern(b *testing.B, expr string, letters int) {
+	p := subexpnames.MustCompile(expr)
+	// 4 matches of the pattern
+	subject := strings.Repeat(strings.Repeat("x", letters)+" ", 4)
+	b.SetBytes(int64(len(subject)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Match(subject)
+	}
+}
+
+func BenchmarkWidePattern(b *testing.B) {
+	benchmarkLargePattern(b, widePattern(400), 400)
+}
+
+func BenchmarkDeepPattern(b *testing.B) {
+	benchmarkLargePattern(b, deepPattern(200), 200)
+}
+
+func BenchmarkBalancedPattern(b *testing.B) {
+	benchmarkLargePattern(b, balancedPattern(4, 4), 256)
+}
+
+func TestNestedAppend(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<a>(?P<b>x)(?P<c>y))(?P<d>(?P<e>z))`)
+	mv, ok := p.MatchFirst("xyz")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	// appending to the Nested of a matchValue must not change the Nested of another.
+	a, d := mv.Nested[0], mv.Nested[1]
+	a.Nested = append(a.Nested, &subexpnames.MatchValue{Key: "extra"})
+	mv.Nested = append(mv.Nested, &subexpnames.MatchValue{Key: "extra"})
+	if len(a.Nested) != 3 || a.Nested[0].Key != "b" || a.Nested[1].Key != "c" {
+		t.Fatalf("unexpected nested values %v", a.Nested)
+	}
+	if len(d.Nested) != 1 || d.Nested[0].Key != "e" || d.Nested[0].Value != "z" {
+		t.Fatalf("unexpected nested values %v", d.Nested)
+	}
+	if leaf := d.Nested[0]; leaf.Nested == nil || len(leaf.Nested) != 0 {
+		t.Fatalf("expected an empty Nested, got %v", leaf.Nested)
+	}
+}
@@ -0,0 +1,369 @@
+package subexpnames
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagName is the name of the struct tag read by Unmarshal.
+const TagName = "subexp"
+
+// InvalidUnmarshalError describes an invalid argument passed to Unmarshal, the argument must be a non-nil pointer to a struct.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+// Error implements the error interface.
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "subexpnames: Unmarshal(nil)"
+	}
+	if e.Type.Kind() != reflect.Pointer {
+		return "subexpnames: Unmarshal(non-pointer " + e.Type.String() + ")"
+	}
+	if e.Type.Elem().Kind() != reflect.Struct {
+		return "subexpnames: Unmarshal(pointer to non-struct " + e.Type.String() + ")"
+	}
+	return "subexpnames: Unmarshal(nil " + e.Type.String() + ")"
+}
+
+// FieldError describes a struct field that Unmarshal cannot fill, because its tag cannot be parsed or its type is not supported.
+type FieldError struct {
+	// Struct is the type of the struct the field belongs to.
+	Struct reflect.Type
+	// Field is the name of the field.
+	Field string
+	// Err is the reason the field cannot be filled.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("subexpnames: field %s.%s: %v", e.Struct, e.Field, e.Err)
+}
+
+// Unwrap returns the reason the field cannot be filled.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalError describes a value that could not be converted to the type of the struct field it is unmarshalled into.
+type UnmarshalError struct {
+	// Field is the name of the field, fields of nested structs are separated by dots.
+	Field string
+	// Path is the path, from the tag of the field, the value was found at.
+	Path string
+	// Value is the value that could not be converted.
+	Value string
+	// Err is the reason the value could not be converted.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("subexpnames: cannot unmarshal %q at %q into field %s: %v", e.Value, e.Path, e.Field, e.Err)
+}
+
+// Unwrap returns the reason the value could not be converted.
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// field describes a struct field filled by Unmarshal.
+type field struct {
+	name  string
+	index []int
+	typ   reflect.Type
+	path  *Path
+	// layout is the layout used to parse time.Time values.
+	layout string
+}
+
+// structFields holds the fields filled by Unmarshal of each struct type, it maps a reflect.Type to a []field.
+var structFields sync.Map
+
+// fieldsOf returns the fields filled by Unmarshal for the struct type t.
+// It returns an error if a tag cannot be parsed or a field has a type that is not supported.
+func fieldsOf(t reflect.Type) ([]field, error) {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]field), nil
+	}
+	fields, err := parseFields(t, make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+	structFields.Store(t, fields)
+	return fields, nil
+}
+
+// parseFields reads the tags of the struct type t, the nested struct types are checked as well.
+// visiting holds the struct types being parsed, so that recursive types are only checked once.
+func parseFields(t reflect.Type, visiting map[reflect.Type]bool) ([]field, error) {
+	visiting[t] = true
+	var fields []field
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, tagged := sf.Tag.Lookup(TagName)
+		if !tagged || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		f := field{name: sf.Name, index: sf.Index, typ: sf.Type, layout: time.RFC3339}
+		expr, options, _ := strings.Cut(tag, ",")
+		path, err := ParsePath(expr)
+		if err != nil {
+			return nil, &FieldError{Struct: t, Field: sf.Name, Err: err}
+		}
+		f.path = path
+		for options != "" {
+			var option string
+			if strings.HasPrefix(options, "layout=") {
+				// the layout is the last option, it can contain commas.
+				option, options = options, ""
+			} else {
+				option, options, _ = strings.Cut(options, ",")
+			}
+			name, value, _ := strings.Cut(option, "=")
+			switch name {
+			case "layout":
+				f.layout = value
+			default:
+				return nil, &FieldError{Struct: t, Field: sf.Name, Err: fmt.Errorf("unknown tag option %q", option)}
+			}
+		}
+		if err := checkType(sf.Type, visiting); err != nil {
+			return nil, &FieldError{Struct: t, Field: sf.Name, Err: err}
+		}
+		if err := checkPromoted(t, sf.Index); err != nil {
+			return nil, &FieldError{Struct: t, Field: sf.Name, Err: err}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// checkPromoted returns an error if the field at index in the struct type t is promoted through an embedded pointer Unmarshal cannot allocate,
+// a pointer to an unexported struct type cannot be set through reflection.
+func checkPromoted(t reflect.Type, index []int) error {
+	v := reflect.New(t).Elem()
+	for _, x := range index[:len(index)-1] {
+		v = v.Field(x)
+		if v.Kind() == reflect.Pointer {
+			if !v.CanSet() {
+				return fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+			}
+			v = reflect.New(v.Type().Elem()).Elem()
+		}
+	}
+	return nil
+}
+
+// isScalar tells whether a value of type t is converted from a single string.
+func isScalar(t reflect.Type) bool {
+	if t == timeType || t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// checkType returns an error if Unmarshal cannot fill a field of type t.
+func checkType(t reflect.Type, visiting map[reflect.Type]bool) error {
+	if isScalar(t) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if !isScalar(t.Elem()) && t.Elem().Kind() != reflect.Struct {
+			break
+		}
+		return checkType(t.Elem(), visiting)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Slice && !isScalar(t.Elem()) {
+			break
+		}
+		return checkType(t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			return nil
+		}
+		_, err := parseFields(t, visiting)
+		return err
+	}
+	return fmt.Errorf("unsupported type %s", t)
+}
+
+// Unmarshal fills the struct pointed to by v with the values of the tree rooted at m.
+//
+// The fields of the struct are mapped to the tree with tags, for example `subexp:"overlap.year"`, the tag is a path relative to m, see Path.
+// Fields without a tag, or with the tag "-", are left untouched. The fields of embedded structs without a tag are filled as if they were fields of the outer struct.
+// A field is filled from the first matchValue selected by its path, capture groups that did not take part in the match are skipped,
+// so fields mapped to missing optional groups keep their zero value and pointers stay nil.
+//
+// Values are converted to the type of the field:
+//   - string, []byte, bool, integers and floats are parsed with strconv.
+//   - time.Duration is parsed with time.ParseDuration.
+//   - time.Time is parsed with time.Parse, using the layout given by the layout option, for example `subexp:"date,layout=2006-01-02"`, the default is time.RFC3339.
+//   - types implementing encoding.TextUnmarshaler are filled by their UnmarshalText method.
+//   - structs are filled from the selected matchValue, with paths relative to it.
+//   - pointers are allocated and filled as the type they point to.
+//   - slices collect every selected matchValue, for example the repeated overlap groups of a pattern.
+//
+// Unmarshal returns a *FieldError if a tag cannot be parsed, a field has a type that is not supported or is promoted through an embedded pointer to an unexported struct,
+// and an *UnmarshalError if a value cannot be converted.
+func Unmarshal(m *MatchValue, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+	return decodeStruct(m, rv.Elem(), "")
+}
+
+// decodeStruct fills the struct v from the tree rooted at m, prefix is the name of the field holding v.
+func decodeStruct(m *MatchValue, v reflect.Value, prefix string) error {
+	fields, err := fieldsOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := range fields {
+		f := &fields[i]
+		var nodes []*MatchValue
+		for _, node := range f.path.Select(m) {
+			if node.Matched {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// the field is promoted through a nil embedded pointer, allocate it.
+			fv = fieldByIndexAlloc(v, f.index)
+		}
+		if err := f.decode(fv, nodes, prefix+f.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates the nil embedded pointers it goes through.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// decode fills the field v from the selected nodes, name is the name of the field used in errors.
+func (f *field) decode(v reflect.Value, nodes []*MatchValue, name string) error {
+	if v.Kind() == reflect.Slice && !isScalar(v.Type()) {
+		s := reflect.MakeSlice(v.Type(), len(nodes), len(nodes))
+		for i, node := range nodes {
+			if err := f.decodeOne(s.Index(i), node, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return f.decodeOne(v, nodes[0], name)
+}
+
+// decodeOne fills v from a single node.
+func (f *field) decodeOne(v reflect.Value, node *MatchValue, name string) error {
+	if isScalar(v.Type()) {
+		if err := setScalar(v, node.Value, f.layout); err != nil {
+			return &UnmarshalError{Field: name, Path: f.path.String(), Value: node.Value, Err: err}
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := f.decodeOne(p.Elem(), node, name); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	default:
+		return decodeStruct(node, v, name+".")
+	}
+}
+
+// setScalar converts s to the type of v and stores it in v, layout is used to parse time.Time values.
+func setScalar(v reflect.Value, s string, layout string) error {
+	switch t := v.Type(); {
+	case t == timeType:
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	case t == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
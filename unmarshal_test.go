@@ -0,0 +1,252 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+type digits struct {
+	Value string `subexp:""`
+	Tens  int    `subexp:"tens"`
+	Ones  int    `subexp:"ones"`
+}
+
+type date struct {
+	Overlap []struct {
+		Value string  `subexp:""`
+		Year  *digits `subexp:"year"`
+		Month *digits `subexp:"month"`
+		Day   *digits `subexp:"day"`
+	} `subexp:"overlap"`
+	Year     int    `subexp:"overlap.year"`
+	Tens     []int  `subexp:"**.tens"`
+	Day      digits `subexp:"overlap[1].day"`
+	Missing  *int   `subexp:"not_found"`
+	Ignored  string `subexp:"-"`
+	Untagged string
+}
+
+func TestUnmarshal(t *testing.T) {
+	re := regexp.MustCompile(`(?P<overlap>(?P<year>(?P<thousands>\d)(?P<hundreds>\d)(?P<tens>\d)(?P<ones>\d))-(?P<month>(?P<tens>\d)(?P<ones>\d)))-(?P<overlap>(?P<day>(?P<tens>\d)(?P<ones>\d)))`)
+	match, ok := subexpnames.MatchFirst(re, "this is a test subject to see if we can parse 2016-01-02 using the Match() function.")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	d := date{Ignored: "ignored", Untagged: "untagged"}
+	if err := subexpnames.Unmarshal(match, &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Overlap) != 2 || d.Overlap[0].Value != "2016-01" || d.Overlap[1].Value != "02" {
+		t.Fatalf("expected 2 overlap values, got %+v", d.Overlap)
+	}
+	if year := d.Overlap[0].Year; year == nil || year.Value != "2016" || year.Tens != 1 || year.Ones != 6 {
+		t.Fatalf("expected year 2016, got %+v", year)
+	}
+	if month := d.Overlap[0].Month; month == nil || month.Value != "01" || month.Ones != 1 {
+		t.Fatalf("expected month 01, got %+v", month)
+	}
+	if d.Overlap[0].Day != nil || d.Overlap[1].Year != nil || d.Overlap[1].Month != nil {
+		t.Fatalf("expected groups missing from an overlap to be nil")
+	}
+	if day := d.Overlap[1].Day; day == nil || day.Value != "02" || day.Ones != 2 {
+		t.Fatalf("expected day 02, got %+v", day)
+	}
+	if d.Year != 2016 {
+		t.Fatalf("expected 2016, got %d", d.Year)
+	}
+	if !slices.Equal(d.Tens, []int{1, 0, 0}) {
+		t.Fatalf("expected [1 0 0], got %v", d.Tens)
+	}
+	if d.Day.Value != "02" || d.Day.Tens != 0 || d.Day.Ones != 2 {
+		t.Fatalf("expected day 02, got %+v", d.Day)
+	}
+	if d.Missing != nil || d.Ignored != "ignored" || d.Untagged != "untagged" {
+		t.Fatalf("expected fields to be left untouched, got %+v", d)
+	}
+}
+
+// level implements encoding.TextUnmarshaler.
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "INFO":
+		*l = 1
+	case "ERROR":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type Common struct {
+	Level level `subexp:"level"`
+}
+
+type entry struct {
+	Common
+	*Extra
+	Time     time.Time     `subexp:"time,layout=2006-01-02 15:04:05"`
+	Elapsed  time.Duration `subexp:"elapsed"`
+	Ratio    float64       `subexp:"ratio"`
+	Cached   bool          `subexp:"cached"`
+	Addr     netip.Addr    `subexp:"addr"`
+	Raw      []byte        `subexp:"addr"`
+	Negative int8          `subexp:"negative"`
+	Year     *int          `subexp:"year"`
+	Missing  string        `subexp:"missing"`
+}
+
+type Extra struct {
+	Code uint16 `subexp:"code"`
+}
+
+func TestUnmarshalTypes(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<time>\S+ \S+) (?P<level>\w+) (?P<elapsed>\S+) (?P<ratio>\S+) (?P<cached>\w+) (?P<addr>\S+) (?P<negative>-?\d+) (?P<code>\d+)(?: (?P<missing>x))?`)
+	match, ok := p.MatchFirst("2024-02-03 04:05:06 ERROR 1.5s 0.25 true 192.168.0.1 -12 404")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	var e entry
+	if err := subexpnames.Unmarshal(match, &e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC); !e.Time.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, e.Time)
+	}
+	if e.Level != 2 || e.Elapsed != 1500*time.Millisecond || e.Ratio != 0.25 || !e.Cached || e.Negative != -12 {
+		t.Fatalf("unexpected values %+v", e)
+	}
+	if e.Addr != netip.MustParseAddr("192.168.0.1") || string(e.Raw) != "192.168.0.1" {
+		t.Fatalf("expected 192.168.0.1, got %v", e.Addr)
+	}
+	if e.Extra == nil || e.Code != 404 {
+		t.Fatalf("expected the embedded pointer to be allocated, got %+v", e.Extra)
+	}
+	if e.Year != nil || e.Missing != "" {
+		t.Fatalf("expected missing groups to be left untouched")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	p := subexpnames.MustCompile(`(?P<number>\w+)`)
+	match, _ := p.MatchFirst("abc")
+
+	var invalid *subexpnames.InvalidUnmarshalError
+	var n int
+	var nilStruct *struct{}
+	for _, v := range []any{nil, struct{}{}, &n, nilStruct} {
+		if err := subexpnames.Unmarshal(match, v); !errors.As(err, &invalid) || err.Error() == "" {
+			t.Fatalf("%T: expected an InvalidUnmarshalError, got %v", v, err)
+		}
+	}
+
+	var number struct {
+		Number []int `subexp:"number"`
+	}
+	err := subexpnames.Unmarshal(match, &number)
+	var unmarshalErr *subexpnames.UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("expected an UnmarshalError, got %v", err)
+	}
+	if unmarshalErr.Field != "Number[0]" || unmarshalErr.Path != "number" || unmarshalErr.Value != "abc" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var nested struct {
+		Inner struct {
+			Level level `subexp:""`
+		} `subexp:"number"`
+	}
+	if err := subexpnames.Unmarshal(match, &nested); !errors.As(err, &unmarshalErr) || unmarshalErr.Field != "Inner.Level" {
+		t.Fatalf("expected an UnmarshalError for Inner.Level, got %v", err)
+	}
+
+	invalidFields := []any{
+		&struct {
+			Bad int `subexp:"a."`
+		}{},
+		&struct {
+			Bad int `subexp:"a,unknown"`
+		}{},
+		&struct {
+			Bad map[string]string `subexp:"a"`
+		}{},
+		&struct {
+			Bad **int `subexp:"a"`
+		}{},
+		&struct {
+			Bad [][]int `subexp:"a"`
+		}{},
+		&struct {
+			Inner struct {
+				Bad chan int `subexp:"a"`
+			} `subexp:"a"`
+		}{},
+	}
+	var fieldErr *subexpnames.FieldError
+	for _, v := range invalidFields {
+		if err := subexpnames.Unmarshal(match, v); !errors.As(err, &fieldErr) || err.Error() == "" {
+			t.Fatalf("%T: expected a FieldError, got %v", v, err)
+		}
+	}
+	if err := subexpnames.Unmarshal(match, invalidFields[0]); errors.Unwrap(err) == nil {
+		t.Fatalf("expected the FieldError to wrap the path error")
+	}
+}
+
+// hidden is an unexported struct type, embedded by value its fields are filled, embedded through a pointer they cannot be.
+type hidden struct {
+	Number string `subexp:"number"`
+}
+
+func TestUnmarshalUnexportedEmbedded(t *testing.T) {
+	match, _ := subexpnames.MustCompile(`(?P<number>\w+)`).MatchFirst("abc")
+
+	var byValue struct{ hidden }
+	if err := subexpnames.Unmarshal(match, &byValue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if byValue.Number != "abc" {
+		t.Fatalf("expected abc, got %q", byValue.Number)
+	}
+
+	var byPointer struct{ *hidden }
+	var fieldErr *subexpnames.FieldError
+	if err := subexpnames.Unmarshal(match, &byPointer); !errors.As(err, &fieldErr) || fieldErr.Field != "Number" {
+		t.Fatalf("expected a FieldError for Number, got %v", err)
+	}
+	if byPointer.hidden != nil {
+		t.Fatalf("expected the embedded pointer to stay nil")
+	}
+}
+
+// list is a recursive type, Unmarshal must not loop forever checking it.
+type list struct {
+	Value string `subexp:""`
+	Next  *list  `subexp:"next"`
+}
+
+func TestUnmarshalRecursiveType(t *testing.T) {
+	match, ok := subexpnames.MustCompile(`(?P<next>a(?P<next>b))`).MatchFirst("ab")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	var l list
+	if err := subexpnames.Unmarshal(match, &l); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Value != "ab" || l.Next == nil || l.Next.Value != "ab" || l.Next.Next == nil || l.Next.Next.Value != "b" || l.Next.Next.Next != nil {
+		t.Fatalf("unexpected list %+v", l)
+	}
+}
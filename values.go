@@ -0,0 +1,132 @@
+package subexpnames
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueError describes a value that cannot be read by a typed accessor, such as Matches.Int or MatchValue.Time.
+type ValueError struct {
+	// Group is the index of the match the value was looked up in, -1 when it was looked up in a MatchValue.
+	Group int
+	// Path is the path the value was looked up at, see Path.
+	Path string
+	// Value is the value that could not be converted, empty when no value was found.
+	Value string
+	// Err is the reason the value could not be read, ErrNotFound if the path does not select a matched capture group
+	// and a *GroupOutOfRangeError if the match is not found.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValueError) Error() string {
+	s := "subexpnames: "
+	if e.Group >= 0 {
+		s += fmt.Sprintf("group %d, ", e.Group)
+	}
+	s += fmt.Sprintf("path %q", e.Path)
+	if e.Value != "" {
+		s += fmt.Sprintf(", value %q", e.Value)
+	}
+	// the reasons this package gives start with its name, it is written once.
+	return s + ": " + strings.TrimPrefix(e.Err.Error(), "subexpnames: ")
+}
+
+// Unwrap returns the reason the value could not be read.
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// parseValue converts the value of the first matched capture group selected by path in the tree rooted at mv with parse.
+// group is the index of mv in its Matches, -1 if none, and is reported in errors.
+func parseValue[T any](mv *MatchValue, group int, path string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	p, err := ParsePath(path)
+	if err != nil {
+		return zero, &ValueError{Group: group, Path: path, Err: err}
+	}
+	for _, node := range p.Select(mv) {
+		if !node.Matched {
+			continue
+		}
+		v, err := parse(node.Value)
+		if err != nil {
+			return zero, &ValueError{Group: group, Path: path, Value: node.Value, Err: err}
+		}
+		return v, nil
+	}
+	return zero, &ValueError{Group: group, Path: path, Err: ErrNotFound}
+}
+
+// parseGroupValue is like parseValue but looks the value up in the group-th match of rm.
+func parseGroupValue[T any](rm *Matches, group int, path string, parse func(string) (T, error)) (T, error) {
+	if group < 0 || group >= len(*rm) {
+		var zero T
+		return zero, &ValueError{Group: group, Path: path, Err: &GroupOutOfRangeError{Group: group, Len: len(*rm)}}
+	}
+	return parseValue((*rm)[group], group, path, parse)
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func timeParser(layout string) func(string) (time.Time, error) {
+	return func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	}
+}
+
+// Int returns the value of the first matched capture group selected by path, see Path, in the tree rooted at mv, parsed as a base 10 integer.
+// It returns a *ValueError if the path is invalid, selects no matched capture group or the value cannot be parsed.
+func (mv *MatchValue) Int(path string) (int, error) {
+	return parseValue(mv, -1, path, strconv.Atoi)
+}
+
+// Float is like Int but parses the value as a float64, see strconv.ParseFloat.
+func (mv *MatchValue) Float(path string) (float64, error) {
+	return parseValue(mv, -1, path, parseFloat)
+}
+
+// Bool is like Int but parses the value as a bool, see strconv.ParseBool.
+func (mv *MatchValue) Bool(path string) (bool, error) {
+	return parseValue(mv, -1, path, strconv.ParseBool)
+}
+
+// Duration is like Int but parses the value as a time.Duration, see time.ParseDuration.
+func (mv *MatchValue) Duration(path string) (time.Duration, error) {
+	return parseValue(mv, -1, path, time.ParseDuration)
+}
+
+// Time is like Int but parses the value as a time.Time with the layout, see time.Parse.
+func (mv *MatchValue) Time(path, layout string) (time.Time, error) {
+	return parseValue(mv, -1, path, timeParser(layout))
+}
+
+// Int returns the value of the first matched capture group selected by path, see Path, in the specified match, parsed as a base 10 integer.
+// It returns a *ValueError if the match is not found, the path is invalid, selects no matched capture group or the value cannot be parsed.
+func (rm *Matches) Int(group int, path string) (int, error) {
+	return parseGroupValue(rm, group, path, strconv.Atoi)
+}
+
+// Float is like Int but parses the value as a float64, see strconv.ParseFloat.
+func (rm *Matches) Float(group int, path string) (float64, error) {
+	return parseGroupValue(rm, group, path, parseFloat)
+}
+
+// Bool is like Int but parses the value as a bool, see strconv.ParseBool.
+func (rm *Matches) Bool(group int, path string) (bool, error) {
+	return parseGroupValue(rm, group, path, strconv.ParseBool)
+}
+
+// Duration is like Int but parses the value as a time.Duration, see time.ParseDuration.
+func (rm *Matches) Duration(group int, path string) (time.Duration, error) {
+	return parseGroupValue(rm, group, path, time.ParseDuration)
+}
+
+// Time is like Int but parses the value as a time.Time with the layout, see time.Parse.
+func (rm *Matches) Time(group int, path, layout string) (time.Time, error) {
+	return parseGroupValue(rm, group, path, timeParser(layout))
+}
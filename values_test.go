@@ -0,0 +1,115 @@
+package subexpnames_test
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func TestTypedValues(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<date>\d{4}-\d{2}-\d{2}) (?P<n>\S+)(?: (?P<ratio>[\d.]+))? (?P<ok>\w+) (?P<took>\w+)`)
+	matches, ok := subexpnames.Match(regex, "2016-01-02 -12 0.5 true 1m30s\n2016-13-02 x yes 2")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+
+	if n, err := matches.Int(0, "n"); err != nil || n != -12 {
+		t.Fatalf("expected -12, got %d %v", n, err)
+	}
+	if f, err := matches.Float(0, "ratio"); err != nil || f != 0.5 {
+		t.Fatalf("expected 0.5, got %f %v", f, err)
+	}
+	if b, err := matches.Bool(0, "ok"); err != nil || !b {
+		t.Fatalf("expected true, got %t %v", b, err)
+	}
+	if d, err := matches.Duration(0, "took"); err != nil || d != 90*time.Second {
+		t.Fatalf("expected 1m30s, got %s %v", d, err)
+	}
+	if tm, err := matches.Time(0, "date", time.DateOnly); err != nil || !tm.Equal(time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 2016-01-02, got %s %v", tm, err)
+	}
+	if n, err := (*matches)[0].Int("n"); err != nil || n != -12 {
+		t.Fatalf("expected -12, got %d %v", n, err)
+	}
+	if b, err := (*matches)[0].Bool("ok"); err != nil || !b {
+		t.Fatalf("expected true, got %t %v", b, err)
+	}
+
+	var numErr *strconv.NumError
+	_, err := matches.Int(1, "n")
+	var valueErr *subexpnames.ValueError
+	if !errors.As(err, &valueErr) || !errors.As(err, &numErr) {
+		t.Fatalf("expected a *ValueError wrapping a *strconv.NumError, got %v", err)
+	}
+	if valueErr.Group != 1 || valueErr.Path != "n" || valueErr.Value != "x" {
+		t.Fatalf("expected group 1, path n and value x, got %d %q %q", valueErr.Group, valueErr.Path, valueErr.Value)
+	}
+	expected := `subexpnames: group 1, path "n", value "x": strconv.Atoi: parsing "x": invalid syntax`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+
+	// ratio does not take part in the second match
+	_, err = matches.Float(1, "ratio")
+	if !errors.Is(err, subexpnames.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if expected := "subexpnames: no matched capture group"; subexpnames.ErrNotFound.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, subexpnames.ErrNotFound)
+	}
+	expected = `subexpnames: group 1, path "ratio": no matched capture group`
+	if err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+	_, err = (*matches)[1].Duration("missing")
+	expected = `subexpnames: path "missing": no matched capture group`
+	if !errors.Is(err, subexpnames.ErrNotFound) || err.Error() != expected {
+		t.Fatalf("expected %s, got %v", expected, err)
+	}
+
+	if _, err := matches.Time(1, "date", time.DateOnly); !errors.As(err, &valueErr) || valueErr.Value != "2016-13-02" {
+		t.Fatalf("expected an error for 2016-13-02, got %v", err)
+	}
+	if _, err := matches.Bool(1, "ok"); !errors.As(err, &valueErr) || valueErr.Value != "yes" {
+		t.Fatalf("expected an error for yes, got %v", err)
+	}
+	if _, err := matches.Duration(1, "took"); !errors.As(err, &valueErr) || valueErr.Value != "2" {
+		t.Fatalf("expected an error for 2, got %v", err)
+	}
+
+	var pathErr *subexpnames.PathError
+	if _, err := matches.Int(0, "n["); !errors.As(err, &pathErr) {
+		t.Fatalf("expected a *PathError, got %v", err)
+	}
+	if _, err := matches.Int(2, "n"); !errors.As(err, &valueErr) || valueErr.Group != 2 {
+		t.Fatalf("expected an error for group 2, got %v", err)
+	}
+	if _, err := matches.Int(-1, "n"); err == nil {
+		t.Fatalf("expected an error for group -1")
+	}
+}
+
+func TestTypedValuesNested(t *testing.T) {
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))(?:-(?P<overlap>(?P<day>\d{2})))?`)
+	matches, ok := subexpnames.Match(regex, "2016-01 2016-01-02")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	if n, err := matches.Int(0, "overlap.year"); err != nil || n != 2016 {
+		t.Fatalf("expected 2016, got %d %v", n, err)
+	}
+	// the second overlap is skipped in the first match, it did not take part in it
+	if _, err := matches.Int(0, "overlap.day"); !errors.Is(err, subexpnames.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if n, err := matches.Int(1, "**.day"); err != nil || n != 2 {
+		t.Fatalf("expected 2, got %d %v", n, err)
+	}
+	if n, err := (*matches)[1].Int("overlap[0].month"); err != nil || n != 1 {
+		t.Fatalf("expected 1, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,61 @@
+package subexpnames
+
+// WalkAction tells Walk how to go on after visiting a matchValue.
+type WalkAction int
+
+const (
+	// Continue visits the nested matchValues and the rest of the tree.
+	Continue WalkAction = iota
+	// SkipChildren skips the matchValues nested in the one being visited, the rest of the tree is still visited.
+	SkipChildren
+	// Stop ends the walk, no other matchValue is visited.
+	Stop
+)
+
+// WalkFunc is the type of the function called by Walk for each matchValue of a tree.
+// path holds the keys leading from the root of the walk to node, it is empty for the root.
+// path is reused between calls and must not be retained or modified, use slices.Clone to keep it.
+type WalkFunc func(path []string, node *MatchValue) WalkAction
+
+// walker holds the state of a walk.
+type walker struct {
+	pre, post WalkFunc
+	path      []string
+}
+
+// walk visits mv and its nested matchValues, it returns false if the walk is stopped.
+func (w *walker) walk(mv *MatchValue) bool {
+	action := Continue
+	if w.pre != nil {
+		action = w.pre(w.path, mv)
+	}
+	if action == Stop {
+		return false
+	}
+	if action != SkipChildren {
+		for _, inner := range mv.Nested {
+			w.path = append(w.path, inner.Key)
+			ok := w.walk(inner)
+			w.path = w.path[:len(w.path)-1]
+			if !ok {
+				return false
+			}
+		}
+	}
+	return w.post == nil || w.post(w.path, mv) != Stop
+}
+
+// Walk visits the tree rooted at m in pre-order: fn is called for a matchValue before its nested matchValues, in the order they appear in the tree.
+// Every matchValue is visited, including those that did not take part in the match.
+// fn returns Continue to go on, SkipChildren to skip the matchValues nested in node and Stop to end the walk.
+func Walk(m *MatchValue, fn WalkFunc) {
+	WalkPrePost(m, fn, nil)
+}
+
+// WalkPrePost is like Walk but calls pre for a matchValue before its nested matchValues are visited, and post after, either of them can be nil.
+// post is called even if pre returned SkipChildren, SkipChildren returned by post is the same as Continue.
+// Once pre or post return Stop no other function is called.
+func WalkPrePost(m *MatchValue, pre, post WalkFunc) {
+	w := &walker{pre: pre, post: post}
+	w.walk(m)
+}
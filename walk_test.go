@@ -0,0 +1,86 @@
+package subexpnames_test
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/thetechpanda/subexpnames"
+)
+
+func walkedTree(t *testing.T) *subexpnames.MatchValue {
+	t.Helper()
+	regex := regexp.MustCompile(`(?P<overlap>(?P<year>\d{4})-(?P<month>\d{2}))-(?P<overlap>(?P<day>\d{2}))(?: (?P<note>\w+))?`)
+	mv, ok := subexpnames.MatchFirst(regex, "2016-01-02")
+	if !ok {
+		t.Fatalf("expected a match")
+	}
+	return mv
+}
+
+func TestWalk(t *testing.T) {
+	mv := walkedTree(t)
+
+	var visited []string
+	subexpnames.Walk(mv, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+		visited = append(visited, strings.Join(path, ".")+"="+node.Value)
+		return subexpnames.Continue
+	})
+	expected := []string{"=2016-01-02", "overlap=2016-01", "overlap.year=2016", "overlap.month=01", "overlap=02", "overlap.day=02", "note="}
+	if !slices.Equal(visited, expected) {
+		t.Fatalf("expected %v, got %v", expected, visited)
+	}
+
+	visited = nil
+	subexpnames.Walk(mv, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+		visited = append(visited, strings.Join(path, "."))
+		if node.Value == "2016-01" {
+			return subexpnames.SkipChildren
+		}
+		if node.Key == "day" {
+			return subexpnames.Stop
+		}
+		return subexpnames.Continue
+	})
+	expected = []string{"", "overlap", "overlap", "overlap.day"}
+	if !slices.Equal(visited, expected) {
+		t.Fatalf("expected %v, got %v", expected, visited)
+	}
+}
+
+func TestWalkPrePost(t *testing.T) {
+	mv := walkedTree(t)
+
+	var events []string
+	pre := func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+		events = append(events, "<"+node.Key)
+		if node.Key == "overlap" && node.Value == "2016-01" {
+			return subexpnames.SkipChildren
+		}
+		return subexpnames.Continue
+	}
+	post := func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+		events = append(events, node.Key+">")
+		if node.Key == "day" {
+			return subexpnames.Stop
+		}
+		return subexpnames.SkipChildren
+	}
+	subexpnames.WalkPrePost(mv, pre, post)
+	expected := []string{"<", "<overlap", "overlap>", "<overlap", "<day", "day>"}
+	if !slices.Equal(events, expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+
+	// a nil pre visits the tree in post-order
+	var paths [][]string
+	subexpnames.WalkPrePost(mv, nil, func(path []string, node *subexpnames.MatchValue) subexpnames.WalkAction {
+		paths = append(paths, slices.Clone(path))
+		return subexpnames.Continue
+	})
+	expectedPaths := [][]string{{"overlap", "year"}, {"overlap", "month"}, {"overlap"}, {"overlap", "day"}, {"overlap"}, {"note"}, {}}
+	if !slices.EqualFunc(paths, expectedPaths, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expectedPaths, paths)
+	}
+}